@@ -0,0 +1,68 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const bedrockHeaderSize = 8
+
+// ErrBedrockLength is returned when the payload length in the header of a
+// Bedrock level.dat does not match the actual length of the NBT payload.
+var ErrBedrockLength = errors.New("payload length mismatch")
+
+// ReadBedrockLevel reads a Bedrock Edition level.dat from the given reader.
+// The little endian NBT payload is prefixed with an 8 byte header, consisting
+// of the storage version and the length of the payload, both little endian
+// int32s. The storage version is returned together with the decoded root tag.
+// If the header length doesn't match the payload, ErrBedrockLength is returned.
+func ReadBedrockLevel(rd io.Reader) (int32, Tag, error) {
+	header := make([]byte, bedrockHeaderSize)
+	if err := read(rd, header); err != nil {
+		return 0, nil, fmt.Errorf("read header: %w", err)
+	}
+	version := int32(binary.LittleEndian.Uint32(header))
+	length := int32(binary.LittleEndian.Uint32(header[4:]))
+	if length < 0 {
+		return 0, nil, fmt.Errorf("negative length %d: %w", length, ErrBedrockLength)
+	}
+
+	// don't trust the length for preallocation, the buffer grows with the actual data
+	var payload bytes.Buffer
+	n, err := io.Copy(&payload, io.LimitReader(rd, int64(length)))
+	if err != nil {
+		return 0, nil, fmt.Errorf("read payload: %w", err)
+	}
+	if n < int64(length) {
+		return 0, nil, fmt.Errorf("payload shorter than %d bytes: %w", length, ErrBedrockLength)
+	}
+
+	source := bytes.NewReader(payload.Bytes())
+	tag, err := NewDecoder(source, binary.LittleEndian).ReadTag()
+	if err != nil {
+		return 0, nil, fmt.Errorf("read tag: %w", err)
+	}
+	if source.Len() != 0 {
+		return 0, nil, fmt.Errorf("%d trailing bytes after tag: %w", source.Len(), ErrBedrockLength)
+	}
+	return version, tag, nil
+}
+
+// WriteBedrockLevel writes the given tag as a Bedrock Edition level.dat onto
+// the given writer, prefixed with a header containing the given storage version
+// and the length of the encoded tag.
+func WriteBedrockLevel(w io.Writer, storageVersion int32, tag Tag) error {
+	var payload bytes.Buffer
+	payload.Write(make([]byte, bedrockHeaderSize)) // reserve space for the header
+	if err := NewEncoder(&payload, binary.LittleEndian).WriteTag(tag); err != nil {
+		return err
+	}
+
+	data := payload.Bytes()
+	binary.LittleEndian.PutUint32(data, uint32(storageVersion))
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-bedrockHeaderSize))
+	return write(w, data)
+}
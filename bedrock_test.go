@@ -0,0 +1,62 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBedrockSuite(t *testing.T) {
+	suite.Run(t, new(BedrockSuite))
+}
+
+type BedrockSuite struct {
+	suite.Suite
+}
+
+func (suite *BedrockSuite) level() Tag {
+	return NewCompoundTag("", []Tag{
+		NewStringTag("LevelName", "My World"),
+		NewIntTag("StorageVersion", 10),
+	})
+}
+
+func (suite *BedrockSuite) TestRoundTrip() {
+	var buf bytes.Buffer
+	suite.NoError(WriteBedrockLevel(&buf, 10, suite.level()))
+
+	data := buf.Bytes()
+	suite.EqualValues(10, binary.LittleEndian.Uint32(data))
+	suite.EqualValues(len(data)-8, binary.LittleEndian.Uint32(data[4:]))
+
+	version, tag, err := ReadBedrockLevel(&buf)
+	suite.NoError(err)
+	suite.EqualValues(10, version)
+	suite.Equal(ToString(suite.level()), ToString(tag))
+}
+
+func (suite *BedrockSuite) TestReadBedrockLevel_LengthMismatch() {
+	var buf bytes.Buffer
+	suite.NoError(WriteBedrockLevel(&buf, 10, suite.level()))
+	data := buf.Bytes()
+
+	// header claims more data than there is
+	long := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(long[4:], uint32(len(data)))
+	_, _, err := ReadBedrockLevel(bytes.NewReader(long))
+	suite.ErrorIs(err, ErrBedrockLength)
+
+	// header claims less data than the tag needs
+	short := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(short[4:], uint32(len(data)-10))
+	_, _, err = ReadBedrockLevel(bytes.NewReader(short))
+	suite.Error(err)
+
+	// trailing data in the payload
+	trailing := append(append([]byte{}, data...), 0, 0)
+	binary.LittleEndian.PutUint32(trailing[4:], uint32(len(trailing)-8))
+	_, _, err = ReadBedrockLevel(bytes.NewReader(trailing))
+	suite.ErrorIs(err, ErrBedrockLength)
+}
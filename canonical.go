@@ -0,0 +1,27 @@
+package nbt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Canonical is an option for the Encoder, that writes the tags of all compounds
+// sorted by name, instead of in insertion order. Logically equal tags thus result
+// in identical bytes, independent of how they were created.
+func Canonical() EncoderOption {
+	return encoderOptionFunc(func(e *encoder) {
+		e.canonical = true
+	})
+}
+
+// CanonicalHash returns the SHA-256 hash of the canonical, big endian encoding of the
+// given tag, including the name of the tag. Logically equal tags have the same hash.
+func CanonicalHash(tag Tag) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	h := sha256.New()
+	if err := NewEncoder(h, binary.BigEndian, Canonical()).WriteTag(tag); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
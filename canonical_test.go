@@ -0,0 +1,71 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCanonicalSuite(t *testing.T) {
+	suite.Run(t, new(CanonicalSuite))
+}
+
+type CanonicalSuite struct {
+	suite.Suite
+}
+
+func (suite *CanonicalSuite) tags() (Tag, Tag) {
+	a := NewCompoundTag("root", []Tag{
+		NewIntTag("b", 1),
+		NewIntTag("a", 2),
+		NewListTag("list", []Tag{
+			NewCompoundTag("", []Tag{NewByteTag("y", 1), NewByteTag("x", 2)}),
+		}, IDTagCompound),
+		NewCompoundTag("nested", []Tag{NewByteTag("d", 3), NewByteTag("c", 4)}),
+	})
+	b := NewCompoundTag("root", []Tag{
+		NewCompoundTag("nested", []Tag{NewByteTag("c", 4), NewByteTag("d", 3)}),
+		NewListTag("list", []Tag{
+			NewCompoundTag("", []Tag{NewByteTag("x", 2), NewByteTag("y", 1)}),
+		}, IDTagCompound),
+		NewIntTag("a", 2),
+		NewIntTag("b", 1),
+	})
+	return a, b
+}
+
+func (suite *CanonicalSuite) encode(tag Tag, opts ...EncoderOption) []byte {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian, opts...).WriteTag(tag))
+	return buf.Bytes()
+}
+
+func (suite *CanonicalSuite) TestCanonical() {
+	a, b := suite.tags()
+	suite.NotEqual(suite.encode(a), suite.encode(b))
+	suite.Equal(suite.encode(a, Canonical()), suite.encode(b, Canonical()))
+	suite.Equal(suite.encode(a, Canonical(), WithCompression(CompressionGzip)), suite.encode(b, Canonical(), WithCompression(CompressionGzip)))
+
+	suite.Equal([]byte{
+		byte(IDTagCompound), 0x00, 0x00,
+		byte(IDTagByte), 0x00, 0x01, 'a', 0x02,
+		byte(IDTagByte), 0x00, 0x01, 'b', 0x01,
+		byte(IDTagEnd),
+	}, suite.encode(NewCompoundTag("", []Tag{NewByteTag("b", 1), NewByteTag("a", 2)}), Canonical()))
+}
+
+func (suite *CanonicalSuite) TestCanonicalHash() {
+	a, b := suite.tags()
+	hashA, err := CanonicalHash(a)
+	suite.NoError(err)
+	hashB, err := CanonicalHash(b)
+	suite.NoError(err)
+	suite.Equal(hashA, hashB)
+
+	b.(*Compound).Put(NewIntTag("a", 3))
+	hashB, err = CanonicalHash(b)
+	suite.NoError(err)
+	suite.NotEqual(hashA, hashB)
+}
@@ -0,0 +1,139 @@
+package nbt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Compression is a compression scheme that NBT data may be wrapped in.
+type Compression uint8
+
+const (
+	// CompressionNone indicates raw, uncompressed NBT data.
+	CompressionNone Compression = iota
+	// CompressionGzip indicates gzip compressed NBT data, as used for
+	// level.dat and playerdata files.
+	CompressionGzip
+	// CompressionZlib indicates zlib compressed NBT data, as used for
+	// chunks in region files.
+	CompressionZlib
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZlib:
+		return "zlib"
+	}
+	return fmt.Sprintf("Compression(%d)", uint8(c))
+}
+
+// WithCompression makes the encoder compress every written tag with the given
+// compression. Every call to WriteTag produces a complete, self-contained
+// compressed stream.
+func WithCompression(c Compression) EncoderOption {
+	return encoderOptionFunc(func(e *encoder) {
+		e.compression = c
+	})
+}
+
+// NewDecompressingDecoder creates a new Decoder just like NewDecoder, but detects
+// whether the data on the source is gzip compressed, zlib compressed or raw NBT,
+// and decompresses it if necessary.
+func NewDecompressingDecoder(source io.Reader, byteOrder binary.ByteOrder, opts ...DecoderOption) (Decoder, error) {
+	rd, _, err := Decompress(source)
+	if err != nil {
+		return nil, err
+	}
+	return NewDecoder(rd, byteOrder, opts...), nil
+}
+
+// UnmarshalCompressedReader works like UnmarshalReader, but detects and removes
+// gzip or zlib compression on the given reader before unmarshalling.
+func UnmarshalCompressedReader(rd io.Reader, order binary.ByteOrder, v interface{}, opts ...DecoderOption) error {
+	source, _, err := Decompress(rd)
+	if err != nil {
+		return err
+	}
+	return UnmarshalReader(source, order, v, opts...)
+}
+
+// Decompress detects the compression of the data on the given reader and
+// returns a reader that yields the decompressed data, together with the
+// detected compression. Raw NBT data is passed through unchanged.
+func Decompress(rd io.Reader) (io.Reader, Compression, error) {
+	buffered := bufio.NewReader(rd)
+	magic, err := buffered.Peek(2)
+	if err != nil && len(magic) == 0 {
+		return nil, CompressionNone, fmt.Errorf("detect compression: %w", err)
+	}
+
+	compression := detectCompression(magic)
+	switch compression {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, compression, fmt.Errorf("gzip: %w", err)
+		}
+		return gz, compression, nil
+	case CompressionZlib:
+		zl, err := zlib.NewReader(buffered)
+		if err != nil {
+			return nil, compression, fmt.Errorf("zlib: %w", err)
+		}
+		return zl, compression, nil
+	}
+	return buffered, compression, nil
+}
+
+// detectCompression detects the compression from the first two bytes of some
+// data. Raw NBT always starts with a tag ID, which is never a valid first byte
+// of a gzip or zlib stream, so the detection is unambiguous.
+func detectCompression(magic []byte) Compression {
+	if len(magic) < 2 {
+		return CompressionNone
+	}
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		return CompressionGzip
+	}
+	// zlib: deflate method in the lower nibble of CMF, a window size of at
+	// most 32K in the upper nibble, and the header checksum (CMF*256 + FLG)
+	// must be a multiple of 31. CMF 0x08 is excluded, as it is also TagString.
+	cmf := magic[0]
+	if cmf >= byte(NumIDTags) && cmf&0x0f == 8 && cmf>>4 <= 7 && (uint16(cmf)<<8|uint16(magic[1]))%31 == 0 {
+		return CompressionZlib
+	}
+	return CompressionNone
+}
+
+func (e encoder) writeCompressed(tag Tag) error {
+	var compressor io.WriteCloser
+	switch e.compression {
+	case CompressionGzip:
+		compressor = gzip.NewWriter(e.w)
+	case CompressionZlib:
+		compressor = zlib.NewWriter(e.w)
+	default:
+		return fmt.Errorf("unsupported compression %s", e.compression)
+	}
+
+	inner := e
+	inner.w = compressor
+	inner.compression = CompressionNone
+	inner.nested = true // already validated
+	if err := inner.WriteTag(tag); err != nil {
+		_ = compressor.Close()
+		return err
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("%s: %w", e.compression, err)
+	}
+	return nil
+}
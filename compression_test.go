@@ -0,0 +1,64 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCompressionSuite(t *testing.T) {
+	suite.Run(t, new(CompressionSuite))
+}
+
+type CompressionSuite struct {
+	suite.Suite
+}
+
+func (suite *CompressionSuite) roundTrip(compression Compression) {
+	tag := NewCompoundTag("Data", []Tag{
+		NewStringTag("LevelName", "world"),
+		NewLongTag("RandomSeed", 1234567890),
+	})
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian, WithCompression(compression)).WriteTag(tag))
+	suite.Equal(compression, detectCompression(buf.Bytes()[:2]))
+
+	dec, err := NewDecompressingDecoder(&buf, binary.BigEndian)
+	suite.Require().NoError(err)
+	got, err := dec.ReadTag()
+	suite.NoError(err)
+	suite.Equal(ToString(tag), ToString(got))
+}
+
+func (suite *CompressionSuite) TestRoundTrip_None() {
+	suite.roundTrip(CompressionNone)
+}
+
+func (suite *CompressionSuite) TestRoundTrip_Gzip() {
+	suite.roundTrip(CompressionGzip)
+}
+
+func (suite *CompressionSuite) TestRoundTrip_Zlib() {
+	suite.roundTrip(CompressionZlib)
+}
+
+func (suite *CompressionSuite) TestDetectCompression_RootString() {
+	// a root string tag whose name length looks like a zlib header checksum
+	suite.Equal(CompressionNone, detectCompression([]byte{0x08, 0x1d}))
+}
+
+func (suite *CompressionSuite) TestUnmarshalCompressedReader() {
+	type level struct {
+		Name string `nbt:"LevelName"`
+		Seed int64  `nbt:"RandomSeed"`
+	}
+	var buf bytes.Buffer
+	suite.NoError(MarshalWriter(&buf, binary.BigEndian, level{"world", 42}, WithCompression(CompressionGzip)))
+
+	var got level
+	suite.NoError(UnmarshalCompressedReader(&buf, binary.BigEndian, &got))
+	suite.Equal(level{"world", 42}, got)
+}
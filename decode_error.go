@@ -0,0 +1,84 @@
+package nbt
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DecodeError is returned by a Decoder, if the data can't be decoded.
+type DecodeError struct {
+	// Offset is the amount of bytes that the Decoder had read from its source when
+	// the error was detected. For compressed data, this is the offset in the
+	// decompressed data.
+	Offset int64
+	// Path is the path of the tag that couldn't be decoded, e.g.
+	// Level.Sections[3].BlockStates. The root tag has an empty path.
+	Path string
+	// Err is the reason why the tag couldn't be decoded.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "root"
+	}
+	return fmt.Sprintf("decode %s at offset %d: %v", path, e.Offset, e.Err)
+}
+
+// Unwrap returns the reason why the tag couldn't be decoded.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// pushPath must be called before the payload of the compound entry with the given
+// name is read. If the payload was read successfully, popPath must be called.
+func pushPath(rd io.Reader, name string) {
+	if r, ok := rd.(*decodeReader); ok {
+		if len(r.path) > 0 {
+			name = "." + name
+		}
+		r.path = append(r.path, name)
+	}
+}
+
+// pushIndex works like pushPath, but for the list element with the given index.
+func pushIndex(rd io.Reader, i uint32) {
+	if r, ok := rd.(*decodeReader); ok {
+		r.path = append(r.path, "["+strconv.FormatUint(uint64(i), 10)+"]")
+	}
+}
+
+func popPath(rd io.Reader) {
+	if r, ok := rd.(*decodeReader); ok {
+		r.path = r.path[:len(r.path)-1]
+	}
+}
+
+// fail records the given error together with the current offset and path, if it
+// is the first error, and returns the given error. Errors must be recorded where
+// they occur, since the path is not popped on errors, but also not complete anymore
+// after the error has been returned by the ReadFrom method of a compound or list.
+func fail(rd io.Reader, err error) error {
+	if r, ok := rd.(*decodeReader); ok && r.err == nil {
+		r.err = err
+		r.errOffset = r.read
+		r.errPath = strings.Join(r.path, "")
+	}
+	return err
+}
+
+// decodeError converts the given error, that was returned while reading a root tag,
+// into a *DecodeError.
+func decodeError(rd io.Reader, err error) error {
+	r, ok := rd.(*decodeReader)
+	if !ok {
+		return err
+	}
+	if r.err == nil {
+		return &DecodeError{Offset: r.read, Err: err}
+	}
+	return &DecodeError{Offset: r.errOffset, Path: r.errPath, Err: r.err}
+}
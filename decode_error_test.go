@@ -0,0 +1,110 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestDecodeErrorSuite(t *testing.T) {
+	suite.Run(t, new(DecodeErrorSuite))
+}
+
+type DecodeErrorSuite struct {
+	suite.Suite
+}
+
+func (suite *DecodeErrorSuite) chunk() []byte {
+	sections := make([]Tag, 4)
+	for i := range sections {
+		sections[i] = NewCompoundTag("", []Tag{
+			NewByteTag("Y", int8(i)),
+			NewLongArrayTag("BlockStates", []int64{1, 2, 3}),
+		})
+	}
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewCompoundTag("", []Tag{
+		NewCompoundTag("Level", []Tag{
+			NewIntTag("xPos", 1),
+			NewListTag("Sections", sections, IDTagCompound),
+		}),
+	})))
+	return buf.Bytes()
+}
+
+func (suite *DecodeErrorSuite) decodeError(err error) *DecodeError {
+	var decodeErr *DecodeError
+	if suite.True(errors.As(err, &decodeErr), "%v", err) {
+		return decodeErr
+	}
+	return &DecodeError{}
+}
+
+func (suite *DecodeErrorSuite) TestTruncated() {
+	data := suite.chunk()
+	truncated := data[:len(data)-10] // within the last long array
+
+	_, err := NewDecoder(bytes.NewReader(truncated), binary.BigEndian).ReadTag()
+	decodeErr := suite.decodeError(err)
+	suite.Equal("Level.Sections[3].BlockStates", decodeErr.Path)
+	suite.Equal(int64(len(truncated)), decodeErr.Offset)
+	suite.ErrorIs(err, io.ErrUnexpectedEOF)
+	suite.Equal("decode Level.Sections[3].BlockStates at offset 222: element 2: unexpected EOF", err.Error())
+
+	// also with projection
+	_, err = NewDecoder(bytes.NewReader(truncated), binary.BigEndian, Project("Level.xPos")).ReadTag()
+	decodeErr = suite.decodeError(err)
+	suite.Equal("Level.Sections", decodeErr.Path)
+	suite.ErrorIs(err, io.ErrUnexpectedEOF)
+}
+
+func (suite *DecodeErrorSuite) TestInvalid() {
+	data := suite.chunk()
+	offset := bytes.Index(data, []byte("Sections")) + len("Sections")
+	data[offset] = 0x42 // list type
+
+	_, err := NewDecoder(bytes.NewReader(data), binary.BigEndian).ReadTag()
+	decodeErr := suite.decodeError(err)
+	suite.Equal("Level.Sections", decodeErr.Path)
+	suite.Equal(int64(offset+5), decodeErr.Offset)
+	suite.ErrorIs(err, ErrListType)
+}
+
+func (suite *DecodeErrorSuite) TestRoot() {
+	_, err := NewDecoder(bytes.NewReader(nil), binary.BigEndian).ReadTag()
+	decodeErr := suite.decodeError(err)
+	suite.Equal("", decodeErr.Path)
+	suite.Equal(int64(0), decodeErr.Offset)
+	suite.ErrorIs(err, io.EOF)
+
+	_, err = NewDecoder(bytes.NewReader([]byte{byte(IDTagInt), 0x00, 0x00, 0x01}), binary.BigEndian).ReadTag()
+	decodeErr = suite.decodeError(err)
+	suite.Equal("", decodeErr.Path)
+	suite.Equal(int64(4), decodeErr.Offset)
+	suite.ErrorIs(err, io.ErrUnexpectedEOF)
+}
+
+func (suite *DecodeErrorSuite) TestOffsetAcrossTags() {
+	data := suite.chunk()
+	stream := append(append([]byte{}, data...), byte(IDTagCompound), 0x00, 0x00, 0x99)
+
+	dec := NewDecoder(bytes.NewReader(stream), binary.BigEndian)
+	_, err := dec.ReadTag()
+	suite.NoError(err)
+	_, err = dec.ReadTag()
+	decodeErr := suite.decodeError(err)
+	suite.Equal("", decodeErr.Path)
+	suite.Equal(int64(len(data)+4), decodeErr.Offset)
+}
+
+func (suite *DecodeErrorSuite) TestLimits() {
+	_, err := NewDecoder(bytes.NewReader(suite.chunk()), binary.BigEndian, MaxLength(3)).ReadTag()
+	decodeErr := suite.decodeError(err)
+	suite.Equal("Level.Sections", decodeErr.Path)
+	suite.ErrorIs(err, ErrLengthLimit)
+}
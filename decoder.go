@@ -1,6 +1,7 @@
 package nbt
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -13,44 +14,139 @@ type Decoder interface {
 	ReadTag() (Tag, error)
 }
 
+// DecoderOption is an option that modifies the behavior of a Decoder.
+type DecoderOption interface {
+	applyDecoder(*decoder)
+}
+
+type decoderOptionFunc func(*decoder)
+
+func (f decoderOptionFunc) applyDecoder(d *decoder) { f(d) }
+
+// Option is an option that applies to both a Decoder and an Encoder.
+type Option interface {
+	DecoderOption
+	EncoderOption
+}
+
 type decoder struct {
 	rd io.Reader
 	bo binary.ByteOrder
+
+	nameless   bool
+	projection *projection
+	limits     decodeLimits
+	raw        bool
+
+	// skipTypeMismatches is only used by UnmarshalReader, see SkipTypeMismatches.
+	skipTypeMismatches bool
+
+	// nested is set for decoders of nested tags, whose errors are converted into a
+	// *DecodeError by the decoder of the root tag.
+	nested bool
 }
 
 // NewDecoder creates a new Decoder that will decode from the given reader and respect
 // the given byte order. The byte order has to be compliant with the byte order of the
 // NBT data in the source.
-func NewDecoder(source io.Reader, byteOrder binary.ByteOrder) Decoder {
-	return &decoder{
+func NewDecoder(source io.Reader, byteOrder binary.ByteOrder, opts ...DecoderOption) Decoder {
+	d := &decoder{
 		rd: source,
 		bo: byteOrder,
 	}
+	_, d.nested = source.(*decodeReader)
+	for _, opt := range opts {
+		opt.applyDecoder(d)
+	}
+	d.rd = d.reader()
+	return d
+}
+
+// reader returns the reader that is passed to the ReadFrom method of a tag. If the
+// source already is a decodeReader, this is a decoder of a nested tag, and the
+// options of the outer decoder continue to apply.
+func (d decoder) reader() io.Reader {
+	if _, ok := d.rd.(*decodeReader); ok {
+		return d.rd
+	}
+	return &decodeReader{
+		Reader: d.rd,
+		limits: d.limits,
+		raw:    d.raw,
+	}
 }
 
+// NamelessRoot is an option for both the Decoder and the Encoder. The root tag is
+// read and written without a name, i.e. only the ID is followed by the payload. The
+// names of nested tags are not affected. This is used by the Java Edition network
+// protocol since 1.20.2 (protocol 764).
+func NamelessRoot() Option {
+	return namelessRoot{}
+}
+
+type namelessRoot struct{}
+
+func (namelessRoot) applyDecoder(d *decoder) { d.nameless = true }
+func (namelessRoot) applyEncoder(e *encoder) { e.nameless = true }
+
+// ReadTag reads the next tag. Errors of the decoder of a root tag are returned as
+// *DecodeError.
 func (d decoder) ReadTag() (Tag, error) {
+	if d.nested {
+		return d.readTag()
+	}
+
+	if r, ok := d.rd.(*decodeReader); ok {
+		r.path = r.path[:0]
+		r.err = nil
+	}
+	tag, err := d.readTag()
+	if err != nil {
+		return nil, decodeError(d.rd, err)
+	}
+	return tag, nil
+}
+
+func (d decoder) readTag() (Tag, error) {
 	idByte, err := readByte(d.rd, d.bo)
 	if err != nil {
-		return nil, fmt.Errorf("read ID: %w", err)
+		if d.nested {
+			err = unexpectedEOF(err) // the compound isn't complete yet
+		}
+		return nil, fail(d.rd, fmt.Errorf("read ID: %w", err))
 	}
 	id := ID(idByte)
 
 	tag, err := newTagFromID(id)
 	if err != nil {
-		return nil, fmt.Errorf("new tag: %w", err)
+		return nil, fail(d.rd, fmt.Errorf("new tag: %w", err))
 	}
 	if tag.ID() == IDTagEnd {
 		return tag, nil
 	}
 
-	name, err := readString(d.rd, d.bo)
-	if err != nil {
-		return nil, fmt.Errorf("read tag name: %w", err)
+	if !d.nameless {
+		name, err := readString(d.rd, d.bo)
+		if err != nil {
+			return nil, fail(d.rd, fmt.Errorf("read tag name: %w", unexpectedEOF(err)))
+		}
+		tag.SetName(name)
 	}
-	tag.SetName(name)
 
-	if err := tag.ReadFrom(d.rd, d.bo); err != nil {
-		return nil, fmt.Errorf("read %s with name '%s' from: %w", tag.ID(), tag.Name(), err)
+	// the path of the root tag is empty
+	if d.nested {
+		pushPath(d.rd, tag.Name())
+	}
+	if compound, ok := tag.(*Compound); ok && d.projection != nil {
+		err = d.projection.readCompound(compound, d.rd, d.bo)
+	} else {
+		err = tag.ReadFrom(d.rd, d.bo)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s with name '%s' from: %w", tag.ID(), tag.Name(), fail(d.rd, unexpectedEOF(err)))
+	}
+	if d.nested {
+		popPath(d.rd)
 	}
 	return tag, nil
 }
@@ -87,16 +183,74 @@ func readUint64(rd io.Reader, bo binary.ByteOrder) (uint64, error) {
 	return bo.Uint64(buf), nil
 }
 
+// readInt32 reads an int value, respecting the varint encoding
+// of the NetworkLittleEndian dialect.
+func readInt32(rd io.Reader, bo binary.ByteOrder) (int32, error) {
+	if isVarint(bo) {
+		return readVarint32(rd, bo)
+	}
+	i, err := readUint32(rd, bo)
+	return int32(i), err
+}
+
+// readInt64 reads a long value, respecting the varint encoding
+// of the NetworkLittleEndian dialect.
+func readInt64(rd io.Reader, bo binary.ByteOrder) (int64, error) {
+	if isVarint(bo) {
+		return readVarint64(rd, bo)
+	}
+	i, err := readUint64(rd, bo)
+	return int64(i), err
+}
+
+// readLength reads the length of a list or an array.
+func readLength(rd io.Reader, bo binary.ByteOrder) (uint32, error) {
+	i, err := readInt32(rd, bo)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("negative length %d", i)
+	}
+	return uint32(i), checkLength(rd, uint32(i))
+}
+
+func readStringLength(rd io.Reader, bo binary.ByteOrder) (uint32, error) {
+	if isVarint(bo) {
+		u, err := readUvarint(rd, bo, binary.MaxVarintLen32)
+		return uint32(u), err
+	}
+	i, err := readUint16(rd, bo)
+	return uint32(i), err
+}
+
 func readString(rd io.Reader, bo binary.ByteOrder) (string, error) {
-	strLen, err := readUint16(rd, bo)
+	strLen, err := readStringLength(rd, bo)
 	if err != nil {
 		return "", fmt.Errorf("read length: %w", err)
 	}
-
-	buf := make([]byte, strLen)
-	if err := read(rd, buf); err != nil {
+	if err := checkStringLength(rd, strLen); err != nil {
 		return "", err
 	}
+
+	var buf []byte
+	if strLen <= maxPreallocation {
+		buf = make([]byte, strLen)
+		if err := read(rd, buf); err != nil {
+			return "", err
+		}
+	} else {
+		// don't trust long lengths for allocating the buffer, it grows while reading instead
+		var b bytes.Buffer
+		if _, err := io.CopyN(&b, rd, int64(strLen)); err != nil {
+			return "", unexpectedEOF(err)
+		}
+		buf = b.Bytes()
+	}
+
+	if decodeStrings(rd, bo) {
+		return DecodeModifiedUTF8(buf), nil
+	}
 	return string(buf), nil
 }
 
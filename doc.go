@@ -1,6 +1,7 @@
 // Package nbt provides encoding and decoding functionality for minecraft's NBT format.
 // This package implements support for both BigEndian and SmallEndian, i.e. for every
-// binary.ByteOrder.
+// binary.ByteOrder. Bedrock Edition's network protocol uses a little endian dialect
+// with varint encoded integers and lengths, which is supported with NetworkLittleEndian.
 //
 // To marshal a struct, use the marshal function as follows.
 //
@@ -34,7 +35,14 @@
 //	// v == 5
 //
 // For (un-)marshalling, there is a struct tag, which supports naming, '-' (ignore while marshalling and unmarshalling)
-// and 'omitempty', which ignores zero values while marshalling.
+// and 'omitempty', which ignores zero values while marshalling. The type of the tag can be overridden with one of
+// 'bool', 'byte', 'short', 'int', 'long', 'float', 'double', 'bytearray', 'intarray', 'longarray' and 'list',
+// e.g. `nbt:"Pos,list"` for a []int32 that must be a list of int tags instead of an int array. The first part of the
+// struct tag is always the name, so the type follows a comma, e.g. `nbt:",byte"`. Marshalling fails if
+// the value doesn't fit into the given type, e.g. a float64 that can't be represented exactly as 'float'. While
+// unmarshalling, such fields also accept tags that can be converted into the given type without losing data.
+// Types can define their own tag representation by implementing nbt.Marshaler and
+// nbt.Unmarshaler, or encoding.TextMarshaler and encoding.TextUnmarshaler for string tags.
 // For reading tags one by one from a reader, the process is similar to encoding.
 //
 //	dec := NewDecoder(myReader, binary.BigEndian)
@@ -42,7 +50,14 @@
 //	fmt.Println(nbt.ToString(tag))
 //
 // This will print whatever one tag was on the reader.
+// To walk large NBT data without building the tags in memory, use nbt.NewTokenReader,
+// which yields the data token by token, and can skip compounds and lists.
+// nbt.NewTokenWriter is its counterpart for encoding.
 // nbt.ToString will print an NBT tag in the above used representation.
+// To convert tags from and to stringified NBT as used in commands, such as
+// {Count:1b,id:"minecraft:stone"}, use nbt.ParseSNBT and nbt.FormatSNBT.
+// For a JSON representation that keeps all type information, use nbt.ToJSON
+// and nbt.FromJSON.
 //
 // Another way of decoding is using the nbt.Mapper. It is designed to unmarshal
 // larger structures, for which unmarshalling is not flexible enough. It is more work
@@ -78,4 +93,9 @@
 // Any error returned will contain a detailed message, what caused the error. Examples are, that the root
 // tag or any tag in the query path except the last element is not a compound, the query path does not
 // exist, or a type didn't match.
+//
+// If only a few paths are needed from large data, pass nbt.Project with the query paths to
+// nbt.NewDecoder. All other tags are skipped without being decoded.
+//
+//	dec := NewDecoder(myReader, binary.BigEndian, nbt.Project("Level.xPos", "Level.zPos"))
 package nbt
@@ -13,28 +13,91 @@ type Encoder interface {
 	WriteTag(Tag) error
 }
 
+// EncoderOption is an option that modifies the behavior of an Encoder.
+type EncoderOption interface {
+	applyEncoder(*encoder)
+}
+
+type encoderOptionFunc func(*encoder)
+
+func (f encoderOptionFunc) applyEncoder(e *encoder) { f(e) }
+
 type encoder struct {
 	w  io.Writer
 	bo binary.ByteOrder
+
+	compression Compression
+	nameless    bool
+	canonical   bool
+	raw         bool
+
+	// nested is set for encoders of nested tags, whose root tag has already been validated.
+	nested bool
+}
+
+// encodeWriter passes the options of an encoder, that also apply to nested tags,
+// through the WriteTo methods of the tags, so that an Encoder created for a nested
+// tag, such as in Compound.WriteTo, inherits them.
+type encodeWriter struct {
+	io.Writer
+	canonical bool
+	raw       bool
 }
 
 // NewEncoder creates a new Encoder that will encode NBT tags
 // with the given byte order and write them on the given writer.
-func NewEncoder(target io.Writer, byteOrder binary.ByteOrder) Encoder {
-	return &encoder{
+func NewEncoder(target io.Writer, byteOrder binary.ByteOrder, opts ...EncoderOption) Encoder {
+	e := &encoder{
 		w:  target,
 		bo: byteOrder,
 	}
+	if ew, ok := target.(*encodeWriter); ok {
+		e.canonical = ew.canonical
+		e.raw = ew.raw
+		e.nested = true
+	}
+	for _, opt := range opts {
+		opt.applyEncoder(e)
+	}
+	return e
+}
+
+// payloadWriter returns the writer that is passed to the WriteTo method of a tag.
+func (e encoder) payloadWriter() io.Writer {
+	if ew, ok := e.w.(*encodeWriter); ok && ew.canonical == e.canonical && ew.raw == e.raw {
+		return ew
+	}
+	return &encodeWriter{
+		Writer:    e.w,
+		canonical: e.canonical,
+		raw:       e.raw,
+	}
 }
 
 func (e encoder) WriteTag(tag Tag) error {
-	if err := writeByte(e.w, e.bo, byte(tag.ID())); err != nil {
+	if !e.nested {
+		if err := validate(tag, e.bo, e.raw); err != nil {
+			return err
+		}
+	}
+
+	if e.compression != CompressionNone {
+		return e.writeCompressed(tag)
+	}
+
+	w := e.payloadWriter()
+	if err := writeByte(w, e.bo, byte(tag.ID())); err != nil {
 		return fmt.Errorf("write ID: %w", err)
 	}
-	if err := writeString(e.w, e.bo, tag.Name()); err != nil {
-		return fmt.Errorf("write tag name: %w", err)
+	if tag.ID() == IDTagEnd {
+		return nil // like when decoding, an end tag has no name
+	}
+	if !e.nameless {
+		if err := writeString(w, e.bo, tag.Name()); err != nil {
+			return fmt.Errorf("write tag name: %w", err)
+		}
 	}
-	if err := tag.WriteTo(e.w, e.bo); err != nil {
+	if err := tag.WriteTo(w, e.bo); err != nil {
 		return fmt.Errorf("write %s with name '%s': %w", tag.Name(), tag.ID(), err)
 	}
 	return nil
@@ -62,11 +125,50 @@ func writeUint64(w io.Writer, order binary.ByteOrder, i uint64) error {
 	return write(w, buf)
 }
 
+// writeInt32 writes an int value, respecting the varint encoding
+// of the NetworkLittleEndian dialect.
+func writeInt32(w io.Writer, order binary.ByteOrder, i int32) error {
+	if isVarint(order) {
+		return writeVarint32(w, order, i)
+	}
+	return writeUint32(w, order, uint32(i))
+}
+
+// writeInt64 writes a long value, respecting the varint encoding
+// of the NetworkLittleEndian dialect.
+func writeInt64(w io.Writer, order binary.ByteOrder, i int64) error {
+	if isVarint(order) {
+		return writeVarint64(w, order, i)
+	}
+	return writeUint64(w, order, uint64(i))
+}
+
+// writeLength writes the length of a list or an array.
+func writeLength(w io.Writer, order binary.ByteOrder, length int) error {
+	return writeInt32(w, order, int32(length))
+}
+
+func writeStringLength(w io.Writer, order binary.ByteOrder, length int) error {
+	if length > maxStringLength(order) {
+		return fmt.Errorf("length %d: %w", length, ErrStringTooLong)
+	}
+	if isVarint(order) {
+		return writeUvarint(w, order, uint64(uint32(length)))
+	}
+	return writeUint16(w, order, uint16(length))
+}
+
 func writeString(w io.Writer, order binary.ByteOrder, s string) error {
-	if err := writeUint16(w, order, uint16(len(s))); err != nil {
+	var buf []byte
+	if encodeStrings(w, order) {
+		buf = EncodeModifiedUTF8(s)
+	} else {
+		buf = []byte(s)
+	}
+	if err := writeStringLength(w, order, len(buf)); err != nil {
 		return fmt.Errorf("write length: %w", err)
 	}
-	return write(w, []byte(s))
+	return write(w, buf)
 }
 
 func writeFloat32(w io.Writer, order binary.ByteOrder, i float32) error {
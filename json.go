@@ -0,0 +1,359 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// The JSON representation of a tag is an object with the type, the name and the value
+// of the tag. The name is omitted if it is empty.
+//
+//	{"type": "int", "name": "x", "value": 5}
+//
+// The type is one of end, byte, short, int, long, float, double, byte_array, string,
+// list, compound, int_array and long_array. Values are represented as follows.
+//
+//	byte, short, int                JSON number
+//	long                            JSON string, since longs exceed the safe integer
+//	                                range of many JSON implementations
+//	float, double                   JSON number, or one of the JSON strings "NaN",
+//	                                "Infinity" and "-Infinity"
+//	string                          JSON string
+//	byte_array, int_array           JSON array of numbers
+//	long_array                      JSON array of strings
+//	list                            JSON object with the element type and the element
+//	                                values, e.g. {"elementType": "int", "elements": [1, 2]}
+//	compound                        JSON array of the tags in the compound
+//
+// This representation keeps all type information, so that converting JSON to a tag
+// and back results in the same JSON.
+
+var (
+	jsonTypeNames = [NumIDTags]string{
+		IDTagEnd:       "end",
+		IDTagByte:      "byte",
+		IDTagShort:     "short",
+		IDTagInt:       "int",
+		IDTagLong:      "long",
+		IDTagFloat:     "float",
+		IDTagDouble:    "double",
+		IDTagByteArray: "byte_array",
+		IDTagString:    "string",
+		IDTagList:      "list",
+		IDTagCompound:  "compound",
+		IDTagIntArray:  "int_array",
+		IDTagLongArray: "long_array",
+	}
+)
+
+type jsonTag struct {
+	Type  string          `json:"type"`
+	Name  string          `json:"name,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+type jsonList struct {
+	ElementType string            `json:"elementType"`
+	Elements    []json.RawMessage `json:"elements"`
+}
+
+// ToJSON converts the given tag into its lossless JSON representation.
+func ToJSON(tag Tag) ([]byte, error) {
+	jt, err := toJSONTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jt)
+}
+
+// FromJSON converts the given JSON, as created by ToJSON, back into a tag.
+func FromJSON(data []byte) (Tag, error) {
+	var jt jsonTag
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return nil, err
+	}
+	return fromJSONTag(jt)
+}
+
+func toJSONTag(tag Tag) (jsonTag, error) {
+	if tag == nil {
+		return jsonTag{}, ErrNilTag
+	}
+	value, err := toJSONValue(tag)
+	if err != nil {
+		return jsonTag{}, fmt.Errorf("%s '%s': %w", tag.ID(), tag.Name(), err)
+	}
+	return jsonTag{
+		Type:  jsonTypeNames[tag.ID()],
+		Name:  tag.Name(),
+		Value: value,
+	}, nil
+}
+
+func toJSONValue(tag Tag) (json.RawMessage, error) {
+	var value interface{}
+	switch tag.ID() {
+	case IDTagEnd:
+		value = nil
+	case IDTagByte:
+		value = tag.(*Byte).Value
+	case IDTagShort:
+		value = tag.(*Short).Value
+	case IDTagInt:
+		value = tag.(*Int).Value
+	case IDTagLong:
+		value = strconv.FormatInt(tag.(*Long).Value, 10)
+	case IDTagFloat:
+		return jsonFloat(float64(tag.(*Float).Value), 32), nil
+	case IDTagDouble:
+		return jsonFloat(tag.(*Double).Value, 64), nil
+	case IDTagString:
+		value = tag.(*String).Value
+	case IDTagByteArray:
+		value = append([]int8{}, tag.(*ByteArray).Value...) // never null
+	case IDTagIntArray:
+		value = append([]int32{}, tag.(*IntArray).Value...)
+	case IDTagLongArray:
+		longs := tag.(*LongArray).Value
+		values := make([]string, len(longs))
+		for i, v := range longs {
+			values[i] = strconv.FormatInt(v, 10)
+		}
+		value = values
+	case IDTagList:
+		list := tag.(*List)
+		if list.ListType >= NumIDTags {
+			return nil, fmt.Errorf("unknown list type %s", list.ListType)
+		}
+		elements := make([]json.RawMessage, len(list.Value))
+		for i, elem := range list.Value {
+			if elem == nil {
+				return nil, fmt.Errorf("element %d: %w", i, ErrNilTag)
+			}
+			if elem.ID() != list.ListType {
+				return nil, fmt.Errorf("element %d: %s in list of %s", i, elem.ID(), list.ListType)
+			}
+			v, err := toJSONValue(elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			elements[i] = v
+		}
+		value = jsonList{
+			ElementType: jsonTypeNames[list.ListType],
+			Elements:    elements,
+		}
+	case IDTagCompound:
+		compound := tag.(*Compound)
+		keys := compound.Keys()
+		entries := make([]jsonTag, 0, len(keys))
+		for _, k := range keys {
+			if compound.Value[k] == nil {
+				return nil, fmt.Errorf("entry '%s': %w", k, ErrNilTag)
+			}
+			entry, err := toJSONTag(compound.Value[k])
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+		value = entries
+	default:
+		return nil, fmt.Errorf("unknown tag ID %s", tag.ID())
+	}
+	return json.Marshal(value)
+}
+
+func jsonFloat(f float64, bitSize int) json.RawMessage {
+	switch {
+	case math.IsNaN(f):
+		return json.RawMessage(`"NaN"`)
+	case math.IsInf(f, 1):
+		return json.RawMessage(`"Infinity"`)
+	case math.IsInf(f, -1):
+		return json.RawMessage(`"-Infinity"`)
+	}
+	return json.RawMessage(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+func fromJSONTag(jt jsonTag) (Tag, error) {
+	id, err := jsonTypeID(jt.Type)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := fromJSONValue(id, jt.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%s '%s': %w", id, jt.Name, err)
+	}
+	tag.SetName(jt.Name)
+	return tag, nil
+}
+
+func jsonTypeID(typ string) (ID, error) {
+	for id, name := range jsonTypeNames {
+		if name == typ {
+			return ID(id), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown type '%s'", typ)
+}
+
+func fromJSONValue(id ID, raw json.RawMessage) (Tag, error) {
+	switch id {
+	case IDTagEnd:
+		return NewEndTag(), nil
+	case IDTagByte:
+		i, err := jsonInt(raw, 8)
+		return NewByteTag("", int8(i)), err
+	case IDTagShort:
+		i, err := jsonInt(raw, 16)
+		return NewShortTag("", int16(i)), err
+	case IDTagInt:
+		i, err := jsonInt(raw, 32)
+		return NewIntTag("", int32(i)), err
+	case IDTagLong:
+		i, err := jsonLong(raw)
+		return NewLongTag("", i), err
+	case IDTagFloat:
+		f, err := jsonParseFloat(raw, 32)
+		return NewFloatTag("", float32(f)), err
+	case IDTagDouble:
+		f, err := jsonParseFloat(raw, 64)
+		return NewDoubleTag("", f), err
+	case IDTagString:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return NewStringTag("", s), err
+	case IDTagByteArray:
+		elems, err := jsonArray(raw)
+		values := make([]int8, len(elems))
+		for i := 0; err == nil && i < len(elems); i++ {
+			var v int64
+			v, err = jsonInt(elems[i], 8)
+			values[i] = int8(v)
+		}
+		return NewByteArrayTag("", values), err
+	case IDTagIntArray:
+		elems, err := jsonArray(raw)
+		values := make([]int32, len(elems))
+		for i := 0; err == nil && i < len(elems); i++ {
+			var v int64
+			v, err = jsonInt(elems[i], 32)
+			values[i] = int32(v)
+		}
+		return NewIntArrayTag("", values), err
+	case IDTagLongArray:
+		elems, err := jsonArray(raw)
+		values := make([]int64, len(elems))
+		for i := 0; err == nil && i < len(elems); i++ {
+			values[i], err = jsonLong(elems[i])
+		}
+		return NewLongArrayTag("", values), err
+	case IDTagList:
+		var list jsonList
+		if err := jsonStrict(raw, &list); err != nil {
+			return nil, err
+		}
+		elemID, err := jsonTypeID(list.ElementType)
+		if err != nil {
+			return nil, err
+		}
+		if elemID == IDTagEnd && len(list.Elements) > 0 {
+			return nil, fmt.Errorf("list of %s can't have elements", elemID)
+		}
+		values := make([]Tag, len(list.Elements))
+		for i, elem := range list.Elements {
+			values[i], err = fromJSONValue(elemID, elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return NewListTag("", values, elemID), nil
+	case IDTagCompound:
+		var entries []jsonTag
+		if err := jsonStrict(raw, &entries); err != nil {
+			return nil, err
+		}
+		compound := NewCompoundTag("", nil)
+		for _, entry := range entries {
+			tag, err := fromJSONTag(entry)
+			if err != nil {
+				return nil, err
+			}
+			if tag.ID() == IDTagEnd {
+				return nil, fmt.Errorf("compound can't contain %s", tag.ID())
+			}
+			if _, ok := compound.Value[tag.Name()]; ok {
+				return nil, fmt.Errorf("duplicate entry '%s'", tag.Name())
+			}
+			compound.Put(tag)
+		}
+		return compound, nil
+	}
+	return nil, fmt.Errorf("unknown tag ID %s", id)
+}
+
+// jsonStrict unmarshals the given JSON into v, but fails on null values.
+func jsonStrict(raw json.RawMessage, v interface{}) error {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) || len(raw) == 0 {
+		return fmt.Errorf("missing value")
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func jsonArray(raw json.RawMessage) ([]json.RawMessage, error) {
+	var elems []json.RawMessage
+	err := jsonStrict(raw, &elems)
+	return elems, err
+}
+
+func jsonInt(raw json.RawMessage, bitSize int) (int64, error) {
+	n, err := jsonNumber(raw)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(n.String(), 10, bitSize)
+}
+
+// jsonNumber unmarshals a JSON number. Other than unmarshalling into a json.Number,
+// this does not accept numbers in quotes.
+func jsonNumber(raw json.RawMessage) (json.Number, error) {
+	var n json.Number
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '"' {
+		return n, fmt.Errorf("expected number, but got %s", trimmed)
+	}
+	err := jsonStrict(raw, &n)
+	return n, err
+}
+
+func jsonLong(raw json.RawMessage) (int64, error) {
+	var s string
+	if err := jsonStrict(raw, &s); err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func jsonParseFloat(raw json.RawMessage, bitSize int) (float64, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		switch s {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return 0, fmt.Errorf("invalid float '%s'", s)
+	}
+
+	n, err := jsonNumber(raw)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(n.String(), bitSize)
+}
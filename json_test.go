@@ -0,0 +1,120 @@
+package nbt
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestJSONSuite(t *testing.T) {
+	suite.Run(t, new(JSONSuite))
+}
+
+type JSONSuite struct {
+	suite.Suite
+}
+
+func (suite *JSONSuite) TestToJSON() {
+	data, err := ToJSON(NewCompoundTag("root", []Tag{
+		NewIntArrayTag("a", []int32{1, 2}),
+		NewListTag("b", []Tag{NewIntTag("", 1), NewIntTag("", 2)}, IDTagInt),
+		NewFloatTag("c", 0.1),
+		NewDoubleTag("d", 0.1),
+		NewListTag("e", nil, IDTagString),
+		NewLongTag("f", math.MaxInt64),
+	}))
+	suite.NoError(err)
+	suite.JSONEq(`{"type": "compound", "name": "root", "value": [
+		{"type": "int_array", "name": "a", "value": [1, 2]},
+		{"type": "list", "name": "b", "value": {"elementType": "int", "elements": [1, 2]}},
+		{"type": "float", "name": "c", "value": 0.1},
+		{"type": "double", "name": "d", "value": 0.1},
+		{"type": "list", "name": "e", "value": {"elementType": "string", "elements": []}},
+		{"type": "long", "name": "f", "value": "9223372036854775807"}
+	]}`, string(data))
+}
+
+func (suite *JSONSuite) TestRoundTrip() {
+	tag := NewCompoundTag("root", []Tag{
+		NewByteTag("byte", -128),
+		NewShortTag("short", -32768),
+		NewIntTag("int", -2147483648),
+		NewLongTag("long", -9223372036854775808),
+		NewFloatTag("float", 0.49823147),
+		NewDoubleTag("double", 0.49312871321823148),
+		NewFloatTag("nan", float32(math.NaN())),
+		NewDoubleTag("inf", math.Inf(-1)),
+		NewStringTag("string", "\"quoted\" ☃"),
+		NewByteArrayTag("bytes", []int8{-128, 0, 127}),
+		NewByteArrayTag("noBytes", nil),
+		NewIntArrayTag("ints", []int32{1, 2, 3}),
+		NewLongArrayTag("longs", []int64{1, -9223372036854775808}),
+		NewListTag("lists", []Tag{
+			NewListTag("", nil, IDTagEnd),
+			NewListTag("", []Tag{NewLongArrayTag("", []int64{1})}, IDTagLongArray),
+		}, IDTagList),
+		NewListTag("compounds", []Tag{
+			NewCompoundTag("", []Tag{NewStringTag("name", "a")}),
+		}, IDTagCompound),
+	})
+
+	data, err := ToJSON(tag)
+	suite.NoError(err)
+	got, err := FromJSON(data)
+	suite.NoError(err)
+	suite.Equal(ToString(tag), ToString(got))
+
+	again, err := ToJSON(got)
+	suite.NoError(err)
+	suite.Equal(string(data), string(again))
+}
+
+func (suite *JSONSuite) TestFromJSON_Invalid() {
+	for _, data := range []string{
+		`{"type": "unknown", "value": 1}`,
+		`{"type": "byte", "value": 128}`,
+		`{"type": "int", "value": 1.5}`,
+		`{"type": "int"}`,
+		`{"type": "long", "value": 1}`,
+		`{"type": "float", "value": "infinity"}`,
+		`{"type": "int_array", "value": [1, null]}`,
+		`{"type": "list", "value": {"elementType": "end", "elements": [1]}}`,
+		`{"type": "list", "value": {"elementType": "int", "elements": ["1"]}}`,
+		`{"type": "compound", "value": [{"type": "end"}]}`,
+		`{"type": "compound", "value": [{"type": "int", "name": "a", "value": 1}, {"type": "int", "name": "a", "value": 2}]}`,
+		`[]`,
+	} {
+		_, err := FromJSON([]byte(data))
+		suite.Error(err, data)
+	}
+}
+
+func (suite *JSONSuite) TestToJSON_InvalidList() {
+	_, err := ToJSON(NewListTag("", []Tag{NewStringTag("", "x")}, IDTagInt))
+	suite.Error(err)
+}
+
+func (suite *JSONSuite) TestFromJSON_DuplicateEntry() {
+	_, err := FromJSON([]byte(`{"type": "compound", "value": [
+		{"type": "int", "name": "a", "value": 1},
+		{"type": "string", "name": "a", "value": "x"}
+	]}`))
+	suite.EqualError(err, "TagCompound '': duplicate entry 'a'")
+}
+
+func (suite *JSONSuite) TestToJSON_Nil() {
+	compound := NewCompoundTag("", nil)
+	compound.Value["a"] = nil
+
+	for _, tag := range []Tag{
+		nil,
+		NewListTag("", []Tag{NewIntTag("", 1), nil}, IDTagInt),
+		compound,
+		NewCompoundTag("", []Tag{compound}),
+	} {
+		_, err := ToJSON(tag)
+		suite.True(errors.Is(err, ErrNilTag), "%v", err)
+	}
+}
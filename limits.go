@@ -0,0 +1,160 @@
+package nbt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Errors that are returned by a Decoder if a limit is exceeded. Check for them
+// with errors.Is.
+var (
+	// ErrDepthLimit is returned if compounds and lists are nested deeper than
+	// allowed by MaxDepth.
+	ErrDepthLimit = errors.New("nesting depth limit exceeded")
+	// ErrBytesLimit is returned if more bytes would have to be read than allowed
+	// by MaxBytes.
+	ErrBytesLimit = errors.New("bytes limit exceeded")
+	// ErrLengthLimit is returned if a list or an array is longer than allowed by
+	// MaxLength.
+	ErrLengthLimit = errors.New("length limit exceeded")
+	// ErrStringLengthLimit is returned if a string or a tag name is longer than
+	// allowed by MaxStringLength.
+	ErrStringLengthLimit = errors.New("string length limit exceeded")
+)
+
+// maxPreallocation is the maximum number of elements that are allocated for an
+// array before the elements have actually been read.
+const maxPreallocation = 1 << 12
+
+type decodeLimits struct {
+	depth        int
+	bytes        int64
+	length       uint32
+	stringLength uint32
+}
+
+func (l decodeLimits) enabled() bool {
+	return l != decodeLimits{}
+}
+
+// MaxDepth is an option for the Decoder, that limits how deep compounds and lists
+// may be nested. The root tag has a depth of 1. If the limit is exceeded, decoding
+// fails with ErrDepthLimit.
+func MaxDepth(depth int) DecoderOption {
+	return decoderOptionFunc(func(d *decoder) {
+		d.limits.depth = depth
+	})
+}
+
+// MaxBytes is an option for the Decoder, that limits how many bytes may be read in total
+// by the decoder. For compressed data, this is the amount of decompressed bytes. If the
+// limit is exceeded, decoding fails with ErrBytesLimit.
+func MaxBytes(n int64) DecoderOption {
+	return decoderOptionFunc(func(d *decoder) {
+		d.limits.bytes = n
+	})
+}
+
+// MaxLength is an option for the Decoder, that limits the amount of elements in a single
+// list or array. If the limit is exceeded, decoding fails with ErrLengthLimit.
+func MaxLength(n int) DecoderOption {
+	return decoderOptionFunc(func(d *decoder) {
+		d.limits.length = clampLimit(n)
+	})
+}
+
+// MaxStringLength is an option for the Decoder, that limits the length in bytes of strings
+// and tag names. If the limit is exceeded, decoding fails with ErrStringLengthLimit.
+func MaxStringLength(n int) DecoderOption {
+	return decoderOptionFunc(func(d *decoder) {
+		d.limits.stringLength = clampLimit(n)
+	})
+}
+
+func clampLimit(n int) uint32 {
+	if n <= 0 || int64(n) > math.MaxUint32 {
+		return 0
+	}
+	return uint32(n)
+}
+
+// decodeReader passes the options of a decoder, that also apply to nested tags, through
+// the ReadFrom methods of the tags, and keeps track of the state that is shared by
+// all tags, such as the amount of bytes read.
+type decodeReader struct {
+	io.Reader
+	limits decodeLimits
+	raw    bool
+
+	read  int64
+	depth int
+
+	// path is the path of the tag that is currently read, see pushPath.
+	path []string
+	// err is the first error that occurred, together with the offset and path
+	// at which it occurred, see fail.
+	err       error
+	errOffset int64
+	errPath   string
+}
+
+func (r *decodeReader) Read(p []byte) (int, error) {
+	if r.limits.bytes > 0 {
+		remaining := r.limits.bytes - r.read
+		if remaining <= 0 {
+			return 0, ErrBytesLimit
+		}
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+	n, err := r.Reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+// enter must be called when a compound or list is read, and returns an error if
+// the maximum depth is exceeded. If no error is returned, leave must be called
+// after the compound or list has been read.
+func enter(rd io.Reader) error {
+	r, ok := rd.(*decodeReader)
+	if !ok {
+		return nil
+	}
+	if r.limits.depth > 0 && r.depth >= r.limits.depth {
+		return fmt.Errorf("depth %d: %w", r.depth+1, ErrDepthLimit)
+	}
+	r.depth++
+	return nil
+}
+
+func leave(rd io.Reader) {
+	if r, ok := rd.(*decodeReader); ok {
+		r.depth--
+	}
+}
+
+func checkLength(rd io.Reader, length uint32) error {
+	if r, ok := rd.(*decodeReader); ok && r.limits.length > 0 && length > r.limits.length {
+		return fmt.Errorf("length %d: %w", length, ErrLengthLimit)
+	}
+	return nil
+}
+
+func checkStringLength(rd io.Reader, length uint32) error {
+	if r, ok := rd.(*decodeReader); ok && r.limits.stringLength > 0 && length > r.limits.stringLength {
+		return fmt.Errorf("string length %d: %w", length, ErrStringLengthLimit)
+	}
+	return nil
+}
+
+// preallocate returns the capacity that is allocated for the given amount of
+// elements, before the elements have been read.
+func preallocate(length uint32) int {
+	if length > maxPreallocation {
+		return maxPreallocation
+	}
+	return int(length)
+}
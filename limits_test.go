@@ -0,0 +1,106 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLimitsSuite(t *testing.T) {
+	suite.Run(t, new(LimitsSuite))
+}
+
+type LimitsSuite struct {
+	suite.Suite
+}
+
+func (suite *LimitsSuite) encode(tag Tag) []byte {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(tag))
+	return buf.Bytes()
+}
+
+func (suite *LimitsSuite) nested(depth int) Tag {
+	var tag Tag = NewIntTag("", 1)
+	for i := 0; i < depth; i++ {
+		if i%2 == 0 {
+			tag = NewListTag("", []Tag{tag}, tag.ID())
+		} else {
+			tag = NewCompoundTag("", []Tag{tag})
+		}
+	}
+	return tag
+}
+
+func (suite *LimitsSuite) TestMaxDepth() {
+	data := suite.encode(suite.nested(10))
+
+	_, err := NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxDepth(10)).ReadTag()
+	suite.NoError(err)
+	_, err = NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxDepth(9)).ReadTag()
+	suite.ErrorIs(err, ErrDepthLimit)
+
+	// the limit also applies when reading tokens
+	r := NewTokenReader(bytes.NewReader(data), binary.BigEndian, MaxDepth(9))
+	for err == nil {
+		_, err = r.Token()
+	}
+	suite.ErrorIs(err, ErrDepthLimit)
+}
+
+func (suite *LimitsSuite) TestMaxDepth_Skip() {
+	data := suite.encode(NewCompoundTag("", []Tag{suite.nested(10)}))
+	_, err := NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxDepth(5), Project("x")).ReadTag()
+	suite.ErrorIs(err, ErrDepthLimit)
+}
+
+func (suite *LimitsSuite) TestMaxBytes() {
+	data := suite.encode(NewStringTag("name", "value"))
+
+	_, err := NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxBytes(int64(len(data)))).ReadTag()
+	suite.NoError(err)
+	_, err = NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxBytes(int64(len(data)-1))).ReadTag()
+	suite.ErrorIs(err, ErrBytesLimit)
+}
+
+func (suite *LimitsSuite) TestMaxLength() {
+	for _, tag := range []Tag{
+		NewListTag("", []Tag{NewByteTag("", 1), NewByteTag("", 2)}, IDTagByte),
+		NewByteArrayTag("", []int8{1, 2}),
+		NewIntArrayTag("", []int32{1, 2}),
+		NewLongArrayTag("", []int64{1, 2}),
+	} {
+		data := suite.encode(tag)
+		_, err := NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxLength(2)).ReadTag()
+		suite.NoError(err)
+		_, err = NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxLength(1)).ReadTag()
+		suite.ErrorIs(err, ErrLengthLimit, tag.ID())
+	}
+}
+
+func (suite *LimitsSuite) TestMaxStringLength() {
+	data := suite.encode(NewCompoundTag("", []Tag{NewStringTag("abc", "x")}))
+	_, err := NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxStringLength(3)).ReadTag()
+	suite.NoError(err)
+	_, err = NewDecoder(bytes.NewReader(data), binary.BigEndian, MaxStringLength(2)).ReadTag()
+	suite.ErrorIs(err, ErrStringLengthLimit)
+}
+
+func (suite *LimitsSuite) TestUntrustedLength() {
+	// arrays that claim to be huge must not be allocated before they are read
+	for _, id := range []ID{IDTagByteArray, IDTagIntArray, IDTagLongArray, IDTagList} {
+		data := []byte{byte(id), 0x00, 0x00}
+		if id == IDTagList {
+			data = append(data, byte(IDTagLong))
+		}
+		data = append(data, 0x7f, 0xff, 0xff, 0xff, 0x01)
+		_, err := NewDecoder(bytes.NewReader(data), binary.BigEndian).ReadTag()
+		suite.ErrorIs(err, io.ErrUnexpectedEOF, id)
+	}
+
+	_, err := NewDecoder(bytes.NewReader([]byte{byte(IDTagIntArray), 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}), binary.BigEndian).ReadTag()
+	suite.Error(err) // negative length
+}
@@ -1,25 +1,39 @@
 package nbt
 
 import (
+	"encoding"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
+	"sort"
 )
 
-// MarshalWriter marshals the given val onto the given writer as an NBT tag.
-// The given byte order is respected.
-func MarshalWriter(w io.Writer, order binary.ByteOrder, val interface{}) error {
-	value := reflect.ValueOf(val)
-	if value.Kind() == reflect.Ptr {
-		return MarshalWriter(w, order, value.Elem())
-	}
+// Marshaler is implemented by types that can marshal themselves into a tag. The name
+// of the returned tag is overwritten with the name of the struct field or map key.
+type Marshaler interface {
+	MarshalNBT() (Tag, error)
+}
 
-	return marshalFrom(w, order, value)
+var (
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// MarshalWriter marshals the given val onto the given writer as an NBT tag.
+// The given byte order is respected, and the given options are passed
+// on to the underlying Encoder. Unexported struct fields are ignored.
+//
+// Values that implement Marshaler are marshalled into the tag that their
+// MarshalNBT method returns. Values that implement encoding.TextMarshaler
+// are marshalled into a string tag.
+func MarshalWriter(w io.Writer, order binary.ByteOrder, val interface{}, opts ...EncoderOption) error {
+	return marshalFrom(w, order, reflect.ValueOf(val), opts...)
 }
 
-func marshalFrom(w io.Writer, order binary.ByteOrder, value reflect.Value) error {
-	enc := NewEncoder(w, order)
+func marshalFrom(w io.Writer, order binary.ByteOrder, value reflect.Value, opts ...EncoderOption) error {
+	enc := NewEncoder(w, order, opts...)
 
 	tag, err := createTag(value)
 	if err != nil {
@@ -32,6 +46,10 @@ func marshalFrom(w io.Writer, order binary.ByteOrder, value reflect.Value) error
 }
 
 func createTag(value reflect.Value) (Tag, error) {
+	if tag, ok, err := createTagFromMarshaler(value); ok {
+		return tag, err
+	}
+
 	var tag Tag
 	switch value.Kind() {
 	case reflect.String:
@@ -48,12 +66,57 @@ func createTag(value reflect.Value) (Tag, error) {
 		tag = NewIntTag("", int32(value.Int()))
 	case reflect.Uint32:
 		tag = NewIntTag("", int32(value.Uint()))
+	case reflect.Int:
+		// like in Java, an int is marshalled into an int tag, but only if it fits
+		v := value.Int()
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("int value %d doesn't fit into %s, use the long type override", v, IDTagInt)
+		}
+		tag = NewIntTag("", int32(v))
+	case reflect.Uint:
+		v := value.Uint()
+		if v > math.MaxUint32 {
+			return nil, fmt.Errorf("uint value %d doesn't fit into %s, use the long type override", v, IDTagInt)
+		}
+		tag = NewIntTag("", int32(v))
 	case reflect.Int64:
 		tag = NewLongTag("", value.Int())
 	case reflect.Uint64:
 		tag = NewLongTag("", int64(value.Uint()))
-	case reflect.Ptr:
+	case reflect.Float32:
+		tag = NewFloatTag("", float32(value.Float()))
+	case reflect.Float64:
+		tag = NewDoubleTag("", value.Float())
+	case reflect.Bool:
+		var b int8
+		if value.Bool() {
+			b = 1
+		}
+		tag = NewByteTag("", b)
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil, fmt.Errorf("unhandled nil %s", value.Type().String())
+		}
 		return createTag(value.Elem())
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unhandled map key type %s", value.Type().Key().String())
+		}
+		tag = NewCompoundTag("", []Tag{})
+
+		// sort the keys, so that the order of the tags is deterministic
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, key := range keys {
+			created, err := createTag(value.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			created.SetName(key.String())
+			tag.(*Compound).Put(created)
+		}
 	case reflect.Struct:
 		tag = NewCompoundTag("", []Tag{})
 
@@ -65,56 +128,238 @@ func createTag(value reflect.Value) (Tag, error) {
 			tagValue := parseStructTag(typeField.Tag.Get(structTag))
 			if tagValue.ignore {
 				continue
+			} else if typeField.PkgPath != "" {
+				// unexported fields can't be unmarshalled, so they're not marshalled either
+				continue
 			} else if field.IsZero() && tagValue.omitempty {
 				continue
 			} else if tagValue.name != "" {
 				name = tagValue.name
 			}
-			created, err := createTag(field)
+			created, err := createTagAs(field, tagValue.typ)
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("field %s: %w", typeField.Name, err)
 			}
 			created.SetName(name)
-			tag.(*Compound).Value[name] = created
+			tag.(*Compound).Put(created)
 		}
 	case reflect.Slice:
+		if implementsMarshaler(value.Type().Elem()) {
+			// the elements define their own tags, so they can't be put into an array
+			return createListTag(value)
+		}
 		switch value.Type().Elem().Kind() {
+		case reflect.Int8:
+			conv := make([]int8, value.Len())
+			for i := 0; i < len(conv); i++ {
+				conv[i] = int8(value.Index(i).Int())
+			}
+			return NewByteArrayTag("", conv), nil
+		case reflect.Uint8:
+			conv := make([]int8, value.Len())
+			for i := 0; i < len(conv); i++ {
+				conv[i] = int8(value.Index(i).Uint())
+			}
+			return NewByteArrayTag("", conv), nil
 		case reflect.Int32:
-			slice := value.Interface().([]int32)
-			return NewIntArrayTag("", slice), nil
+			conv := make([]int32, value.Len())
+			for i := 0; i < len(conv); i++ {
+				conv[i] = int32(value.Index(i).Int())
+			}
+			return NewIntArrayTag("", conv), nil
 		case reflect.Int64:
-			slice := value.Interface().([]int64)
-			return NewLongArrayTag("", slice), nil
+			conv := make([]int64, value.Len())
+			for i := 0; i < len(conv); i++ {
+				conv[i] = value.Index(i).Int()
+			}
+			return NewLongArrayTag("", conv), nil
 		case reflect.Uint32:
-			slice := value.Interface().([]uint32)
-			conv := make([]int32, len(slice))
+			conv := make([]int32, value.Len())
 			for i := 0; i < len(conv); i++ {
-				conv[i] = int32(slice[i])
+				conv[i] = int32(value.Index(i).Uint())
 			}
 			return NewIntArrayTag("", conv), nil
 		case reflect.Uint64:
-			slice := value.Interface().([]uint64)
-			conv := make([]int64, len(slice))
+			conv := make([]int64, value.Len())
 			for i := 0; i < len(conv); i++ {
-				conv[i] = int64(slice[i])
+				conv[i] = int64(value.Index(i).Uint())
 			}
 			return NewLongArrayTag("", conv), nil
 		default:
-			var tags []Tag
-			for i := 0; i < value.Len(); i++ {
-				created, err := createTag(value.Index(i))
-				if err != nil {
-					return nil, err
-				}
-				tags = append(tags, created)
-			}
-			if len(tags) == 0 {
-				return nil, fmt.Errorf("empty slice unhandled")
-			}
-			return NewListTag("", tags, tags[0].ID()), nil
+			return createListTag(value)
 		}
+	case reflect.Invalid:
+		return nil, fmt.Errorf("unhandled nil value")
 	default:
 		return nil, fmt.Errorf("unhandled type %s", value.Type().String())
 	}
 	return tag, nil
 }
+
+// createTagAs works like createTag, but creates a tag of the type given by a type override
+// of a struct tag. Numbers are converted from the Go value, and an error is returned if
+// the value doesn't fit into the type.
+func createTagAs(value reflect.Value, typ string) (Tag, error) {
+	if typ == "" {
+		return createTag(value)
+	}
+	if tag, ok, err := createTagFromMarshaler(value); ok {
+		if err != nil {
+			return nil, err
+		}
+		return convertTag(tag, typ)
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil, fmt.Errorf("unhandled nil %s", value.Type().String())
+		}
+		return createTagAs(value.Elem(), typ)
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch typ {
+		case structTagBool, structTagByte, structTagShort, structTagInt, structTagLong:
+			v, err := goIntValue(value, typ)
+			if err != nil {
+				return nil, err
+			}
+			return newIntTag("", typ, v)
+		case structTagFloat, structTagDouble:
+			switch value.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				f, exact := intToFloat(value.Int(), typ)
+				return newFloatTag("", typ, f, exact)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				f, exact := uintToFloat(value.Uint(), typ)
+				return newFloatTag("", typ, f, exact)
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if typ == structTagFloat || typ == structTagDouble {
+			return newFloatTag("", typ, value.Float(), floatFits(value.Float(), typ))
+		}
+	case reflect.Slice:
+		if typ != structTagByteArray && typ != structTagIntArray && typ != structTagLongArray {
+			break
+		}
+		if elemType := value.Type().Elem(); implementsMarshaler(elemType) || !isInt(elemType) && elemType.Kind() != reflect.Bool {
+			break
+		}
+		values := make([]int64, value.Len())
+		for i := range values {
+			v, err := goIntValue(value.Index(i), typ)
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			values[i] = v
+		}
+		return newArrayTag("", typ, values)
+	}
+
+	tag, err := createTag(value)
+	if err != nil {
+		return nil, err
+	}
+	return convertTag(tag, typ)
+}
+
+// goIntValue returns the value of the given bool or integer as value of an integer tag
+// of the type given by a type override. Like in createTag, the bits of unsigned values
+// are reinterpreted, e.g. an uint8 with value 255 becomes a byte tag with value -1.
+func goIntValue(value reflect.Value, typ string) (int64, error) {
+	bits := intBits(typ)
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v := value.Int()
+		if typ == structTagBool && v != 0 {
+			v = 1
+		}
+		if !intFitsBits(v, bits) {
+			return 0, fmt.Errorf("value %d doesn't fit into %s", v, typ)
+		}
+		return v, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v := value.Uint()
+		if typ == structTagBool && v != 0 {
+			v = 1
+		}
+		if bits < 64 && v >= 1<<bits {
+			return 0, fmt.Errorf("value %d doesn't fit into %s", v, typ)
+		}
+		shift := 64 - bits
+		return int64(v<<shift) >> shift, nil
+	}
+	return 0, fmt.Errorf("can't convert %s to %s", value.Type(), typ)
+}
+
+// createListTag creates a list tag with a tag for every element of the given slice.
+func createListTag(value reflect.Value) (Tag, error) {
+	var tags []Tag
+	for i := 0; i < value.Len(); i++ {
+		created, err := createTag(value.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, created)
+	}
+	if len(tags) == 0 {
+		// like vanilla, empty lists have the element type end
+		return NewListTag("", nil, IDTagEnd), nil
+	}
+	return NewListTag("", tags, tags[0].ID()), nil
+}
+
+// implementsMarshaler returns whether values of the given type implement Marshaler or
+// encoding.TextMarshaler, either directly or through a pointer.
+func implementsMarshaler(typ reflect.Type) bool {
+	ptrType := reflect.PtrTo(typ)
+	return typ.Implements(marshalerType) || typ.Implements(textMarshalerType) ||
+		ptrType.Implements(marshalerType) || ptrType.Implements(textMarshalerType)
+}
+
+// createTagFromMarshaler creates the tag for the given value, if the value implements
+// Marshaler or encoding.TextMarshaler, either directly or through a pointer.
+func createTagFromMarshaler(value reflect.Value) (Tag, bool, error) {
+	if !value.IsValid() || !value.CanInterface() || (value.Kind() == reflect.Ptr && value.IsNil()) {
+		return nil, false, nil
+	}
+	if value.Kind() != reflect.Ptr {
+		ptrType := reflect.PtrTo(value.Type())
+		if !value.Type().Implements(marshalerType) && !value.Type().Implements(textMarshalerType) &&
+			(ptrType.Implements(marshalerType) || ptrType.Implements(textMarshalerType)) {
+			// the methods have pointer receivers, so call them on an addressable copy
+			if value.CanAddr() {
+				value = value.Addr()
+			} else {
+				ptr := reflect.New(value.Type())
+				ptr.Elem().Set(value)
+				value = ptr
+			}
+		}
+	}
+
+	switch m := value.Interface().(type) {
+	case Marshaler:
+		tag, err := m.MarshalNBT()
+		if err != nil {
+			return nil, true, fmt.Errorf("marshal %s: %w", value.Type(), err)
+		}
+		if tag == nil {
+			return nil, true, fmt.Errorf("marshal %s: %w", value.Type(), ErrNilTag)
+		}
+		return tag, true, nil
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, true, fmt.Errorf("marshal %s: %w", value.Type(), err)
+		}
+		return NewStringTag("", string(text)), true, nil
+	}
+	return nil, false, nil
+}
@@ -3,7 +3,13 @@ package nbt
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -25,6 +31,15 @@ func (suite *MarshalSuite) expect(t Tag, val interface{}) {
 	suite.equalTag(t, tag)
 }
 
+// roundTrip marshals the given val and unmarshals it into the given target, which
+// must then be equal to want.
+func (suite *MarshalSuite) roundTrip(val, target, want interface{}) {
+	var buf bytes.Buffer
+	suite.Require().NoError(MarshalWriter(&buf, binary.BigEndian, val))
+	suite.Require().NoError(UnmarshalReader(&buf, binary.BigEndian, target))
+	suite.Equal(want, reflect.ValueOf(target).Elem().Interface())
+}
+
 func (suite *MarshalSuite) equalTag(expected, got Tag) {
 	if expected == nil || got == nil {
 		if expected == got {
@@ -44,6 +59,12 @@ func (suite *MarshalSuite) equalTag(expected, got Tag) {
 		suite.Equal(expected.(*Short).Value, got.(*Short).Value)
 	case IDTagInt:
 		suite.Equal(expected.(*Int).Value, got.(*Int).Value)
+	case IDTagFloat:
+		suite.Equal(expected.(*Float).Value, got.(*Float).Value)
+	case IDTagDouble:
+		suite.Equal(expected.(*Double).Value, got.(*Double).Value)
+	case IDTagByteArray:
+		suite.Equal(expected.(*ByteArray).Value, got.(*ByteArray).Value)
 	case IDTagIntArray:
 		suite.Equal(expected.(*IntArray).Value, got.(*IntArray).Value)
 	case IDTagLong:
@@ -51,14 +72,17 @@ func (suite *MarshalSuite) equalTag(expected, got Tag) {
 	case IDTagLongArray:
 		suite.Equal(expected.(*LongArray).Value, got.(*LongArray).Value)
 	case IDTagList:
+		suite.Equal(expected.(*List).ListType, got.(*List).ListType)
 		expectedValues := expected.(*List).Value
 		gotValues := got.(*List).Value
+		suite.Require().Len(gotValues, len(expectedValues))
 		for i := range expectedValues {
 			suite.equalTag(expectedValues[i], gotValues[i])
 		}
 	case IDTagCompound:
 		expectedMap := expected.(*Compound).Value
 		gotMap := got.(*Compound).Value
+		suite.Equal(expected.(*Compound).Keys(), got.(*Compound).Keys())
 		for k, v := range expectedMap {
 			suite.equalTag(v, gotMap[k])
 		}
@@ -93,6 +117,101 @@ func (suite *MarshalSuite) TestMarshalWriter_Long() {
 	suite.expect(NewLongTag("", 7), uint64(7))
 }
 
+func (suite *MarshalSuite) TestMarshalWriter_Float() {
+	suite.expect(NewFloatTag("", 1.5), float32(1.5))
+	suite.expect(NewDoubleTag("", 1.5), 1.5)
+
+	var f32 float32
+	suite.roundTrip(float32(-0.25), &f32, float32(-0.25))
+	var f64 float64
+	suite.roundTrip(12.125, &f64, 12.125)
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_Bool() {
+	suite.expect(NewByteTag("", 1), true)
+	suite.expect(NewByteTag("", 0), false)
+
+	var b bool
+	suite.roundTrip(true, &b, true)
+	suite.roundTrip(false, &b, false)
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_ByteArray() {
+	suite.expect(NewByteArrayTag("", []int8{1, -2, 3}), []int8{1, -2, 3})
+	suite.expect(NewByteArrayTag("", []int8{1, -2, 3}), []byte{1, 0xfe, 3})
+	suite.expect(NewByteArrayTag("", []int8{}), []byte{})
+
+	var i8 []int8
+	suite.roundTrip([]int8{1, -2, 3}, &i8, []int8{1, -2, 3})
+	var b []byte
+	suite.roundTrip([]byte{1, 0xfe, 3}, &b, []byte{1, 0xfe, 3})
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_Map() {
+	suite.expect(NewCompoundTag("", []Tag{
+		NewIntTag("a", 1),
+		NewIntTag("b", 2),
+		NewIntTag("c", 3),
+	}), map[string]int32{"c": 3, "a": 1, "b": 2})
+	suite.expect(NewCompoundTag("", []Tag{
+		NewDoubleTag("X", 1.5),
+		NewStringTag("id", "minecraft:pig"),
+	}), map[string]interface{}{"id": "minecraft:pig", "X": 1.5})
+	suite.expect(NewCompoundTag("", []Tag{}), map[string]string{})
+
+	type pos struct {
+		X, Y, Z float64
+	}
+	var target pos
+	suite.roundTrip(map[string]float64{"X": 1, "Y": 64, "Z": -3.5}, &target, pos{1, 64, -3.5})
+	var m map[string]float64
+	suite.roundTrip(map[string]float64{"X": 1, "Y": 64, "Z": -3.5}, &m, map[string]float64{"X": 1, "Y": 64, "Z": -3.5})
+	var i map[string]interface{}
+	suite.roundTrip(map[string]interface{}{"id": "minecraft:pig", "Age": int32(3)}, &i, map[string]interface{}{"id": "minecraft:pig", "Age": int32(3)})
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_MapInvalidKey() {
+	suite.Error(MarshalWriter(ioutil.Discard, binary.BigEndian, map[int]string{1: "a"}))
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_Pointer() {
+	v := int32(7)
+	suite.expect(NewIntTag("", 7), &v)
+	suite.expect(NewListTag("", []Tag{NewIntTag("", 7)}, IDTagInt), []*int32{&v})
+	suite.Error(MarshalWriter(ioutil.Discard, binary.BigEndian, (*int32)(nil)))
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_EmptyList() {
+	suite.expect(NewListTag("", nil, IDTagEnd), []string{})
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_Entity() {
+	type entity struct {
+		Pos      []float64
+		Rotation []float32
+		OnGround bool
+		Data     []byte
+		Tags     map[string]string
+	}
+	in := entity{
+		Pos:      []float64{1.5, 64, -20.25},
+		Rotation: []float32{90, -12.5},
+		OnGround: true,
+		Data:     []byte{0, 1, 0xff},
+		Tags:     map[string]string{"CustomName": "Babe"},
+	}
+	suite.expect(NewCompoundTag("", []Tag{
+		NewListTag("Pos", []Tag{NewDoubleTag("", 1.5), NewDoubleTag("", 64), NewDoubleTag("", -20.25)}, IDTagDouble),
+		NewListTag("Rotation", []Tag{NewFloatTag("", 90), NewFloatTag("", -12.5)}, IDTagFloat),
+		NewByteTag("OnGround", 1),
+		NewByteArrayTag("Data", []int8{0, 1, -1}),
+		NewCompoundTag("Tags", []Tag{NewStringTag("CustomName", "Babe")}),
+	}), in)
+
+	var out entity
+	suite.roundTrip(in, &out, in)
+}
+
 func (suite *MarshalSuite) TestMarshalWriter_IntArray() {
 	suite.expect(NewIntArrayTag("", []int32{1, 2, 3}), []int32{1, 2, 3})
 	suite.expect(NewIntArrayTag("", []int32{1, 2, 3}), []uint32{1, 2, 3})
@@ -218,3 +337,336 @@ func (suite *MarshalSuite) TestMarshalWriter_StructTag_Omitempty() {
 		Z: "", // empty value that must be omitted
 	})
 }
+
+// testUUID is marshalled like UUIDs in vanilla, i.e. as int array with 4 elements.
+type testUUID [16]byte
+
+func (u testUUID) MarshalNBT() (Tag, error) {
+	ints := make([]int32, 4)
+	for i := range ints {
+		ints[i] = int32(binary.BigEndian.Uint32(u[i*4:]))
+	}
+	return NewIntArrayTag("", ints), nil
+}
+
+func (u *testUUID) UnmarshalNBT(tag Tag) error {
+	arr, ok := tag.(*IntArray)
+	if !ok || len(arr.Value) != 4 {
+		return fmt.Errorf("UUID must be an int array with 4 elements")
+	}
+	for i, v := range arr.Value {
+		binary.BigEndian.PutUint32(u[i*4:], uint32(v))
+	}
+	return nil
+}
+
+// testResourceLocation is marshalled as string tag, such as "minecraft:stone".
+type testResourceLocation struct {
+	Namespace, Path string
+}
+
+func (r testResourceLocation) MarshalText() ([]byte, error) {
+	return []byte(r.Namespace + ":" + r.Path), nil
+}
+
+func (r *testResourceLocation) UnmarshalText(text []byte) error {
+	parts := strings.SplitN(string(text), ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid resource location %q", text)
+	}
+	r.Namespace, r.Path = parts[0], parts[1]
+	return nil
+}
+
+// testBlockPos implements Marshaler with a pointer receiver.
+type testBlockPos struct {
+	X, Y, Z int32
+}
+
+func (p *testBlockPos) MarshalNBT() (Tag, error) {
+	return NewIntArrayTag("", []int32{p.X, p.Y, p.Z}), nil
+}
+
+type testFailingMarshaler struct{}
+
+func (testFailingMarshaler) MarshalNBT() (Tag, error) {
+	return nil, errors.New("always fails")
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_Marshaler() {
+	uuid := testUUID{0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0xff, 0xff, 0xff, 0xff}
+	suite.expect(NewIntArrayTag("", []int32{1, 2, 3, -1}), uuid)
+	suite.expect(NewIntArrayTag("", []int32{1, 2, 3, -1}), &uuid)
+	suite.expect(NewListTag("", []Tag{
+		NewIntArrayTag("", []int32{1, 2, 3, -1}),
+	}, IDTagIntArray), []testUUID{uuid})
+
+	type entity struct {
+		UUID  testUUID
+		Owner *testUUID
+		Pos   testBlockPos
+	}
+	suite.expect(NewCompoundTag("", []Tag{
+		NewIntArrayTag("UUID", []int32{1, 2, 3, -1}),
+		NewIntArrayTag("Owner", []int32{1, 2, 3, -1}),
+		NewIntArrayTag("Pos", []int32{4, 5, 6}),
+	}), entity{UUID: uuid, Owner: &uuid, Pos: testBlockPos{4, 5, 6}})
+	suite.expect(NewIntArrayTag("", []int32{4, 5, 6}), testBlockPos{4, 5, 6})
+
+	var out testUUID
+	suite.roundTrip(uuid, &out, uuid)
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_MarshalerError() {
+	err := MarshalWriter(ioutil.Discard, binary.BigEndian, []testFailingMarshaler{{}})
+	suite.Error(err)
+	suite.Contains(err.Error(), "always fails")
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_TextMarshaler() {
+	loc := testResourceLocation{"minecraft", "stone"}
+	suite.expect(NewStringTag("", "minecraft:stone"), loc)
+	suite.expect(NewCompoundTag("", []Tag{
+		NewStringTag("id", "minecraft:stone"),
+	}), map[string]testResourceLocation{"id": loc})
+
+	var out testResourceLocation
+	suite.roundTrip(loc, &out, loc)
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_UnexportedField() {
+	type t struct {
+		A int32
+		b int32
+		// must not be walked into, because its TextMarshaler can't be called
+		c time.Time
+	}
+	val := t{A: 1, b: 2, c: time.Unix(0, 0)}
+	suite.expect(NewCompoundTag("", []Tag{
+		NewIntTag("A", 1),
+	}), val)
+
+	var out t
+	suite.roundTrip(val, &out, t{A: 1})
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_GoInt() {
+	suite.expect(NewIntTag("", -7), -7)
+	suite.expect(NewIntTag("", 7), uint(7))
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_StructTag_Type() {
+	type t struct {
+		Pos      []int32 `nbt:"Pos,list"`
+		Count    int     `nbt:"Count,byte"`
+		Damage   int     `nbt:",short"`
+		Time     int32   `nbt:",long"`
+		Flags    []bool  `nbt:"Flags,bytearray"`
+		Active   int8    `nbt:"Active,bool"`
+		OnGround bool    `nbt:",byte"`
+		Health   float64 `nbt:",float"`
+		Motion   []int64 `nbt:",intarray"`
+		Empty    []int32 `nbt:",list"`
+	}
+	suite.expect(NewCompoundTag("", []Tag{
+		NewListTag("Pos", []Tag{NewIntTag("", 1), NewIntTag("", 2), NewIntTag("", 3)}, IDTagInt),
+		NewByteTag("Count", 64),
+		NewShortTag("Damage", 5),
+		NewLongTag("Time", 1000),
+		NewByteArrayTag("Flags", []int8{1, 0, 1}),
+		NewByteTag("Active", 1),
+		NewByteTag("OnGround", 1),
+		NewFloatTag("Health", 20),
+		NewIntArrayTag("Motion", []int32{1, -1}),
+		NewListTag("Empty", nil, IDTagInt),
+	}), t{
+		Pos:      []int32{1, 2, 3},
+		Count:    64,
+		Damage:   5,
+		Time:     1000,
+		Flags:    []bool{true, false, true},
+		Active:   5,
+		OnGround: true,
+		Health:   20,
+		Motion:   []int64{1, -1},
+		Empty:    []int32{},
+	})
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_StructTag_TypeMismatch() {
+	type t struct {
+		Name string `nbt:",int"`
+	}
+	err := MarshalWriter(ioutil.Discard, binary.BigEndian, t{"a"})
+	suite.EqualError(err, "field Name: can't convert TagString to int")
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_StructTag_Vanilla() {
+	// an item stack as written by vanilla
+	var want bytes.Buffer
+	suite.NoError(NewEncoder(&want, binary.BigEndian).WriteTag(NewCompoundTag("", []Tag{
+		NewByteTag("Count", 1),
+		NewStringTag("id", "minecraft:diamond_sword"),
+		NewCompoundTag("tag", []Tag{
+			NewIntTag("Damage", 12),
+			NewByteTag("Unbreakable", 1),
+			NewListTag("Enchantments", []Tag{
+				NewCompoundTag("", []Tag{
+					NewStringTag("id", "minecraft:sharpness"),
+					NewShortTag("lvl", 5),
+				}),
+			}, IDTagCompound),
+		}),
+	})))
+
+	type enchantment struct {
+		ID    testResourceLocation `nbt:"id"`
+		Level int                  `nbt:"lvl,short"`
+	}
+	type itemTag struct {
+		Damage       int
+		Unbreakable  bool `nbt:",bool"`
+		Enchantments []enchantment
+	}
+	type item struct {
+		Count int                  `nbt:",byte"`
+		ID    testResourceLocation `nbt:"id"`
+		Tag   itemTag              `nbt:"tag"`
+	}
+	in := item{
+		Count: 1,
+		ID:    testResourceLocation{"minecraft", "diamond_sword"},
+		Tag: itemTag{
+			Damage:      12,
+			Unbreakable: true,
+			Enchantments: []enchantment{
+				{ID: testResourceLocation{"minecraft", "sharpness"}, Level: 5},
+			},
+		},
+	}
+
+	var got bytes.Buffer
+	suite.NoError(MarshalWriter(&got, binary.BigEndian, in))
+	suite.Equal(want.Bytes(), got.Bytes())
+
+	var out item
+	suite.NoError(UnmarshalReader(bytes.NewReader(want.Bytes()), binary.BigEndian, &out))
+	suite.Equal(in, out)
+}
+
+// testGameMode is an integer type, that is marshalled as string tag, but can be
+// unmarshalled from both string and int tags.
+type testGameMode int32
+
+var testGameModes = []string{"survival", "creative"}
+
+func (m testGameMode) MarshalNBT() (Tag, error) {
+	return NewStringTag("", testGameModes[m]), nil
+}
+
+func (m *testGameMode) UnmarshalNBT(tag Tag) error {
+	switch tag := tag.(type) {
+	case *String:
+		for i, name := range testGameModes {
+			if name == tag.Value {
+				*m = testGameMode(i)
+				return nil
+			}
+		}
+	case *Int:
+		if tag.Value >= 0 && int(tag.Value) < len(testGameModes) {
+			*m = testGameMode(tag.Value)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid game mode %s", strings.TrimSpace(ToString(tag)))
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_MarshalerSliceElements() {
+	suite.expect(NewListTag("", []Tag{
+		NewStringTag("", "creative"),
+		NewStringTag("", "survival"),
+	}, IDTagString), []testGameMode{1, 0})
+
+	var out []testGameMode
+	suite.roundTrip([]testGameMode{1, 0}, &out, []testGameMode{1, 0})
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_GoIntOverflow() {
+	suite.Error(MarshalWriter(ioutil.Discard, binary.BigEndian, 1<<40))
+	suite.Error(MarshalWriter(ioutil.Discard, binary.BigEndian, uint(1<<40)))
+
+	type t struct {
+		X int `nbt:"X"`
+	}
+	err := MarshalWriter(ioutil.Discard, binary.BigEndian, t{X: 1 << 31})
+	suite.EqualError(err, "field X: int value 2147483648 doesn't fit into TagInt, use the long type override")
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_StructTag_TypeFromGoValue() {
+	// overrides must be applied to the Go value, not to the default tag of the value
+	type t struct {
+		X int     `nbt:"X,long"`
+		U uint64  `nbt:",double"`
+		S uint8   `nbt:",short"`
+		N int64   `nbt:",int"`
+		F int     `nbt:",float"`
+		A []int   `nbt:",longarray"`
+		B []uint8 `nbt:",bytearray"`
+	}
+	suite.expect(NewCompoundTag("", []Tag{
+		NewLongTag("X", 1<<40),
+		NewDoubleTag("U", 1<<63),
+		NewShortTag("S", 200),
+		NewIntTag("N", -1<<31),
+		NewFloatTag("F", 1<<24),
+		NewLongArrayTag("A", []int64{1 << 40, -1}),
+		NewByteArrayTag("B", []int8{1, -1}),
+	}), t{
+		X: 1 << 40,
+		U: 1 << 63,
+		S: 200,
+		N: -1 << 31,
+		F: 1 << 24,
+		A: []int{1 << 40, -1},
+		B: []uint8{1, 0xff},
+	})
+}
+
+func (suite *MarshalSuite) TestMarshalWriter_StructTag_TypeOverflow() {
+	tests := []struct {
+		name string
+		val  interface{}
+		err  string
+	}{
+		{"int to byte", struct {
+			Count int `nbt:",byte"`
+		}{300}, "field Count: value 300 doesn't fit into byte"},
+		{"int64 to int", struct {
+			Time int64 `nbt:",int"`
+		}{1 << 40}, "field Time: value 1099511627776 doesn't fit into int"},
+		{"uint16 to byte", struct {
+			Flags uint16 `nbt:",byte"`
+		}{256}, "field Flags: value 256 doesn't fit into byte"},
+		{"int to float", struct {
+			F int `nbt:",float"`
+		}{1<<24 + 1}, "field F: value 1.6777216e+07 can't be represented exactly as float"},
+		{"float64 to float", struct {
+			F float64 `nbt:",float"`
+		}{0.1}, "field F: value 0.1 can't be represented exactly as float"},
+		{"float64 to float overflow", struct {
+			F float64 `nbt:",float"`
+		}{1e300}, "field F: value 1e+300 can't be represented exactly as float"},
+		{"int slice to int array", struct {
+			A []int64 `nbt:",intarray"`
+		}{[]int64{1, 1 << 40}}, "field A: index 1: value 1099511627776 doesn't fit into intarray"},
+		{"marshaler to short", struct {
+			UUID testUUID `nbt:",short"`
+		}{}, "field UUID: can't convert TagIntArray to short"},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.EqualError(MarshalWriter(ioutil.Discard, binary.BigEndian, tt.val), tt.err)
+		})
+	}
+}
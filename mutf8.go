@@ -0,0 +1,145 @@
+package nbt
+
+import (
+	"encoding/binary"
+	"io"
+	"unicode/utf8"
+)
+
+// Java Edition encodes strings and tag names in Java's Modified UTF-8, as written by
+// DataOutput.writeUTF. It differs from UTF-8 in two ways. NUL is encoded as the two
+// bytes 0xC0 0x80, and supplementary characters are encoded as a surrogate pair, where
+// each surrogate is encoded in three bytes (CESU-8). Bedrock Edition uses plain UTF-8.
+//
+// Strings are converted between Modified UTF-8 and UTF-8 for big endian byte orders,
+// unless the RawStrings option is used.
+
+// RawStrings is an option for both the Decoder and the Encoder. Strings and tag names
+// are read and written as they are, without converting them from or to Modified
+// UTF-8. Use DecodeModifiedUTF8 and EncodeModifiedUTF8 to convert them manually.
+func RawStrings() Option {
+	return rawStrings{}
+}
+
+type rawStrings struct{}
+
+func (rawStrings) applyDecoder(d *decoder) { d.raw = true }
+func (rawStrings) applyEncoder(e *encoder) { e.raw = true }
+
+// isModifiedUTF8 returns whether strings are encoded in Modified UTF-8 in the given
+// byte order, which is the case for big endian byte orders.
+func isModifiedUTF8(order binary.ByteOrder) bool {
+	return order.Uint16([]byte{0x00, 0x01}) == 1
+}
+
+func decodeStrings(rd io.Reader, order binary.ByteOrder) bool {
+	if r, ok := rd.(*decodeReader); ok && r.raw {
+		return false
+	}
+	return isModifiedUTF8(order)
+}
+
+func encodeStrings(w io.Writer, order binary.ByteOrder) bool {
+	if ew, ok := w.(*encodeWriter); ok && ew.raw {
+		return false
+	}
+	return isModifiedUTF8(order)
+}
+
+// DecodeModifiedUTF8 converts the given Modified UTF-8 into a UTF-8 string. Decoding
+// is lenient. A raw NUL byte and supplementary characters in plain UTF-8 are accepted,
+// and all other bytes that are not valid Modified UTF-8 are kept as they are, except
+// for unpaired surrogates, which are replaced with utf8.RuneError.
+func DecodeModifiedUTF8(b []byte) string {
+	if !needsModifiedUTF8Decoding(b) {
+		return string(b)
+	}
+
+	buf := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		switch {
+		case b[i] == 0xc0 && i+1 < len(b) && b[i+1] == 0x80:
+			buf = append(buf, 0x00)
+			i += 2
+		case isEncodedSurrogate(b[i:]):
+			r := decodeSurrogate(b[i:])
+			i += 3
+			if r < 0xdc00 && isEncodedSurrogate(b[i:]) {
+				if low := decodeSurrogate(b[i:]); low >= 0xdc00 {
+					r = 0x10000 + (r-0xd800)<<10 + (low - 0xdc00)
+					i += 3
+				}
+			}
+			buf = appendRune(buf, r) // unpaired surrogates become utf8.RuneError
+		default:
+			buf = append(buf, b[i])
+			i++
+		}
+	}
+	return string(buf)
+}
+
+// EncodeModifiedUTF8 converts the given UTF-8 string into Modified UTF-8. Bytes that
+// are not valid UTF-8 are kept as they are.
+func EncodeModifiedUTF8(s string) []byte {
+	if !needsModifiedUTF8Encoding(s) {
+		return []byte(s)
+	}
+
+	buf := make([]byte, 0, len(s)+len(s)/2)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == 0:
+			buf = append(buf, 0xc0, 0x80)
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, s[i])
+		case size == 4:
+			r -= 0x10000
+			buf = appendSurrogate(buf, 0xd800+(r>>10))
+			buf = appendSurrogate(buf, 0xdc00+(r&0x3ff))
+		default:
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return buf
+}
+
+func needsModifiedUTF8Decoding(b []byte) bool {
+	for _, c := range b {
+		if c == 0xc0 || c == 0xed {
+			return true
+		}
+	}
+	return false
+}
+
+func needsModifiedUTF8Encoding(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x00 || s[i] >= 0xf0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isEncodedSurrogate returns whether the given bytes start with a surrogate, encoded
+// in three bytes, i.e. 0xED 0xA0-0xBF 0x80-0xBF.
+func isEncodedSurrogate(b []byte) bool {
+	return len(b) >= 3 && b[0] == 0xed && b[1]&0xe0 == 0xa0 && b[2]&0xc0 == 0x80
+}
+
+func decodeSurrogate(b []byte) rune {
+	return rune(b[0]&0x0f)<<12 | rune(b[1]&0x3f)<<6 | rune(b[2]&0x3f)
+}
+
+func appendSurrogate(buf []byte, r rune) []byte {
+	return append(buf, 0xe0|byte(r>>12), 0x80|byte(r>>6)&0x3f, 0x80|byte(r)&0x3f)
+}
+
+func appendRune(buf []byte, r rune) []byte {
+	var enc [utf8.UTFMax]byte
+	n := utf8.EncodeRune(enc[:], r)
+	return append(buf, enc[:n]...)
+}
@@ -0,0 +1,90 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestModifiedUTF8Suite(t *testing.T) {
+	suite.Run(t, new(ModifiedUTF8Suite))
+}
+
+type ModifiedUTF8Suite struct {
+	suite.Suite
+}
+
+func (suite *ModifiedUTF8Suite) TestEncodeDecode() {
+	for s, encoded := range map[string][]byte{
+		"":        {},
+		"abc":     []byte("abc"),
+		"Äö€":     []byte("Äö€"),
+		"a\x00b":  {'a', 0xc0, 0x80, 'b'},
+		"😀":       {0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80},
+		"x😀\x00🎉": {'x', 0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80, 0xc0, 0x80, 0xed, 0xa0, 0xbc, 0xed, 0xbe, 0x89},
+	} {
+		suite.Equal(encoded, EncodeModifiedUTF8(s), s)
+		suite.Equal(s, DecodeModifiedUTF8(encoded), s)
+	}
+}
+
+func (suite *ModifiedUTF8Suite) TestDecodeLenient() {
+	suite.Equal("a\x00b", DecodeModifiedUTF8([]byte("a\x00b")))
+	suite.Equal("😀", DecodeModifiedUTF8([]byte("😀")))
+	suite.Equal("\xff\xfe", DecodeModifiedUTF8([]byte{0xff, 0xfe}))
+	suite.Equal("�x", DecodeModifiedUTF8([]byte{0xed, 0xa0, 0xbd, 'x'}))              // unpaired high surrogate
+	suite.Equal("��", DecodeModifiedUTF8([]byte{0xed, 0xb8, 0x80, 0xed, 0xb8, 0x80})) // two low surrogates
+	suite.Equal("\xc0", DecodeModifiedUTF8([]byte{0xc0}))
+}
+
+func (suite *ModifiedUTF8Suite) TestTags() {
+	tag := NewCompoundTag("😀", []Tag{NewStringTag("sign\x00", "Hello 🎉")})
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(tag))
+	suite.True(bytes.Contains(buf.Bytes(), []byte{0x00, 0x06, 0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80}))
+	suite.True(bytes.Contains(buf.Bytes(), []byte{0x00, 0x06, 's', 'i', 'g', 'n', 0xc0, 0x80}))
+	got, err := NewDecoder(bytes.NewReader(buf.Bytes()), binary.BigEndian).ReadTag()
+	suite.NoError(err)
+	suite.Equal(ToString(tag), ToString(got))
+
+	// little endian is used by Bedrock Edition, which uses plain UTF-8
+	buf.Reset()
+	suite.NoError(NewEncoder(&buf, binary.LittleEndian).WriteTag(tag))
+	suite.True(bytes.Contains(buf.Bytes(), []byte("😀")))
+	got, err = NewDecoder(&buf, binary.LittleEndian).ReadTag()
+	suite.NoError(err)
+	suite.Equal(ToString(tag), ToString(got))
+}
+
+func (suite *ModifiedUTF8Suite) TestRawStrings() {
+	raw := string([]byte{0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80})
+	tag := NewCompoundTag("", []Tag{NewStringTag("s", raw)})
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian, RawStrings()).WriteTag(tag))
+	suite.True(bytes.Contains(buf.Bytes(), []byte(raw)))
+
+	got, err := NewDecoder(bytes.NewReader(buf.Bytes()), binary.BigEndian, RawStrings()).ReadTag()
+	suite.NoError(err)
+	suite.Equal(ToString(tag), ToString(got))
+
+	got, err = NewDecoder(bytes.NewReader(buf.Bytes()), binary.BigEndian).ReadTag()
+	suite.NoError(err)
+	suite.Equal(ToString(NewCompoundTag("", []Tag{NewStringTag("s", "😀")})), ToString(got))
+
+	tok := NewTokenReader(bytes.NewReader(buf.Bytes()), binary.BigEndian, RawStrings())
+	_, err = tok.Token()
+	suite.NoError(err)
+	s, err := tok.Token()
+	suite.NoError(err)
+	suite.Equal(raw, s.Value)
+
+	buf.Reset()
+	w := NewTokenWriter(&buf, binary.BigEndian, RawStrings())
+	suite.NoError(w.WriteString("", raw))
+	suite.NoError(w.Close())
+	suite.Equal(append([]byte{byte(IDTagString), 0x00, 0x00, 0x00, 0x06}, raw...), buf.Bytes())
+}
@@ -1,6 +1,7 @@
 package nbt
 
 import (
+	"bytes"
 	"encoding/binary"
 	"io"
 	"io/ioutil"
@@ -117,3 +118,45 @@ func (suite *NBTSuite) TestBigTest() {
 	})
 	suite.testAndCompareOutput("bigtest", tag)
 }
+
+func (suite *NBTSuite) TestBigTest_Reencode() {
+	input, err := afero.ReadFile(suite.testdata, "bigtest.input")
+	suite.NoError(err)
+
+	tag, err := NewDecoder(bytes.NewReader(input), binary.BigEndian).ReadTag()
+	suite.NoError(err)
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(tag))
+	suite.Equal(input, buf.Bytes())
+}
+
+func (suite *NBTSuite) TestCompoundOrder() {
+	compound := NewCompoundTag("", []Tag{
+		NewIntTag("c", 1),
+		NewIntTag("a", 2),
+		NewIntTag("b", 3),
+	})
+	suite.Equal([]string{"c", "a", "b"}, compound.Keys())
+
+	compound.Put(NewIntTag("a", 4)) // replaced in place
+	compound.Put(NewIntTag("d", 5))
+	compound.Delete("c")
+	compound.Delete("x")
+	suite.Equal([]string{"a", "b", "d"}, compound.Keys())
+	suite.Equal(ToString(NewIntTag("a", 4)), ToString(compound.Tags()[0]))
+
+	// tags added to or removed from Value directly
+	compound.Value["z"] = NewIntTag("z", 6)
+	compound.Value["y"] = NewIntTag("y", 7)
+	delete(compound.Value, "b")
+	suite.Equal([]string{"a", "d", "y", "z"}, compound.Keys())
+	compound.Put(NewIntTag("b", 8))
+	suite.Equal([]string{"a", "d", "b", "y", "z"}, compound.Keys())
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(compound))
+	decoded, err := NewDecoder(&buf, binary.BigEndian).ReadTag()
+	suite.NoError(err)
+	suite.Equal([]string{"a", "d", "b", "y", "z"}, decoded.(*Compound).Keys())
+}
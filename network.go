@@ -0,0 +1,76 @@
+package nbt
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// NetworkLittleEndian is the NBT dialect used by Bedrock Edition's network protocol.
+// It is little endian, but int and long values, as well as list and array lengths,
+// are encoded as zig-zag varints, and string lengths are encoded as unsigned varints.
+// Use it wherever a byte order is accepted, for example
+//
+//	dec := nbt.NewDecoder(packet, nbt.NetworkLittleEndian)
+var NetworkLittleEndian binary.ByteOrder = networkLittleEndian{binary.LittleEndian}
+
+type networkLittleEndian struct {
+	binary.ByteOrder
+}
+
+func (networkLittleEndian) String() string {
+	return "NetworkLittleEndian"
+}
+
+// errVarintOverflow is returned if a varint is longer than the type it
+// is decoded into allows.
+var errVarintOverflow = errors.New("varint overflows")
+
+func isVarint(order binary.ByteOrder) bool {
+	_, ok := order.(networkLittleEndian)
+	return ok
+}
+
+func readUvarint(rd io.Reader, bo binary.ByteOrder, maxBytes int) (uint64, error) {
+	var val uint64
+	for i := 0; i < maxBytes; i++ {
+		b, err := readByte(rd, bo)
+		if err != nil {
+			return 0, err
+		}
+		val |= uint64(b&0x7f) << (7 * uint(i))
+		if b&0x80 == 0 {
+			return val, nil
+		}
+	}
+	return 0, errVarintOverflow
+}
+
+func readVarint32(rd io.Reader, bo binary.ByteOrder) (int32, error) {
+	u, err := readUvarint(rd, bo, binary.MaxVarintLen32)
+	if err != nil {
+		return 0, err
+	}
+	return int32(uint32(u)>>1) ^ -int32(u&1), nil
+}
+
+func readVarint64(rd io.Reader, bo binary.ByteOrder) (int64, error) {
+	u, err := readUvarint(rd, bo, binary.MaxVarintLen64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func writeUvarint(w io.Writer, _ binary.ByteOrder, u uint64) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return write(w, buf[:binary.PutUvarint(buf, u)])
+}
+
+func writeVarint32(w io.Writer, bo binary.ByteOrder, i int32) error {
+	return writeUvarint(w, bo, uint64(uint32(i<<1)^uint32(i>>31)))
+}
+
+func writeVarint64(w io.Writer, bo binary.ByteOrder, i int64) error {
+	return writeUvarint(w, bo, uint64(i<<1)^uint64(i>>63))
+}
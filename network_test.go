@@ -0,0 +1,114 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestNetworkSuite(t *testing.T) {
+	suite.Run(t, new(NetworkSuite))
+}
+
+type NetworkSuite struct {
+	suite.Suite
+}
+
+func (suite *NetworkSuite) TestEncoding() {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, NetworkLittleEndian).WriteTag(NewCompoundTag("", []Tag{
+		NewIntTag("i", 300),
+		NewLongTag("l", -2),
+		NewShortTag("s", 1),
+		NewListTag("li", []Tag{NewByteTag("", 5)}, IDTagByte),
+		NewIntArrayTag("ia", []int32{-1}),
+	})))
+
+	suite.Equal([]byte{
+		byte(IDTagCompound), 0x00,
+		byte(IDTagInt), 0x01, 'i', 0xd8, 0x04,
+		byte(IDTagLong), 0x01, 'l', 0x03,
+		byte(IDTagShort), 0x01, 's', 0x01, 0x00,
+		byte(IDTagList), 0x02, 'l', 'i', byte(IDTagByte), 0x02, 0x05,
+		byte(IDTagIntArray), 0x02, 'i', 'a', 0x02, 0x01,
+		byte(IDTagEnd),
+	}, buf.Bytes())
+}
+
+func (suite *NetworkSuite) TestRoundTrip() {
+	tag := NewCompoundTag("root", []Tag{
+		NewIntTag("minInt", -2147483648),
+		NewIntTag("maxInt", 2147483647),
+		NewLongTag("minLong", -9223372036854775808),
+		NewLongTag("maxLong", 9223372036854775807),
+		NewFloatTag("float", 1.5),
+		NewDoubleTag("double", -2.5),
+		NewStringTag("string", "Hello, Bedrock!"),
+		NewByteArrayTag("bytes", []int8{-1, 0, 1}),
+		NewIntArrayTag("ints", []int32{-1, 0, 1 << 30}),
+		NewLongArrayTag("longs", []int64{-1, 0, 1 << 62}),
+		NewListTag("list", []Tag{
+			NewCompoundTag("", []Tag{NewStringTag("name", "a")}),
+		}, IDTagCompound),
+	})
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, NetworkLittleEndian).WriteTag(tag))
+	got, err := NewDecoder(&buf, NetworkLittleEndian).ReadTag()
+	suite.NoError(err)
+	suite.Equal(ToString(tag), ToString(got))
+}
+
+func (suite *NetworkSuite) TestMarshalUnmarshal() {
+	type item struct {
+		Name  string `nbt:"Name"`
+		Count int8   `nbt:"Count"`
+		Data  int32  `nbt:"Damage"`
+	}
+
+	var buf bytes.Buffer
+	suite.NoError(MarshalWriter(&buf, NetworkLittleEndian, item{"minecraft:stone", 64, -12}))
+	var got item
+	suite.NoError(UnmarshalReader(&buf, NetworkLittleEndian, &got))
+	suite.Equal(item{"minecraft:stone", 64, -12}, got)
+}
+
+func (suite *NetworkSuite) TestVarintOverflow() {
+	data := []byte{byte(IDTagInt), 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+	_, err := NewDecoder(bytes.NewReader(data), NetworkLittleEndian).ReadTag()
+	suite.ErrorIs(err, errVarintOverflow)
+}
+
+func (suite *NetworkSuite) TestNamelessRoot() {
+	tag := NewCompoundTag("ignored", []Tag{
+		NewStringTag("text", "hi"),
+	})
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian, NamelessRoot()).WriteTag(tag))
+	suite.Equal([]byte{
+		byte(IDTagCompound),
+		byte(IDTagString), 0x00, 0x04, 't', 'e', 'x', 't', 0x00, 0x02, 'h', 'i',
+		byte(IDTagEnd),
+	}, buf.Bytes())
+
+	got, err := NewDecoder(&buf, binary.BigEndian, NamelessRoot()).ReadTag()
+	suite.NoError(err)
+	suite.Equal("", got.Name())
+	suite.Equal(ToString(NewCompoundTag("", []Tag{NewStringTag("text", "hi")})), ToString(got))
+}
+
+func (suite *NetworkSuite) TestNamelessRoot_MarshalUnmarshal() {
+	type chat struct {
+		Text string `nbt:"text"`
+	}
+
+	var buf bytes.Buffer
+	suite.NoError(MarshalWriter(&buf, binary.BigEndian, chat{"hello"}, NamelessRoot()))
+	suite.Equal(byte(IDTagString), buf.Bytes()[1]) // no name after the root ID
+	var got chat
+	suite.NoError(UnmarshalReader(&buf, binary.BigEndian, &got, NamelessRoot()))
+	suite.Equal(chat{"hello"}, got)
+}
@@ -0,0 +1,110 @@
+package nbt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Project is an option for the Decoder, that only decodes the tags at the given query
+// paths, in the same syntax that Mapper.Query uses, e.g. "Level.xPos". All other tags
+// are skipped without being decoded. The root tag is thus returned as a compound, that
+// contains only the tags at the given paths, together with the compounds on the way to
+// them. If the root tag is not a compound, it is decoded completely.
+func Project(paths ...string) DecoderOption {
+	return decoderOptionFunc(func(d *decoder) {
+		if d.projection == nil {
+			d.projection = &projection{}
+		}
+		for _, path := range paths {
+			d.projection.add(path)
+		}
+	})
+}
+
+// projection is a tree of the query paths that have to be decoded.
+type projection struct {
+	// all indicates, that the complete tag has to be decoded.
+	all      bool
+	children map[string]*projection
+}
+
+func (p *projection) add(path string) {
+	if path == "" {
+		p.all = true
+		return
+	}
+	current := p
+	for _, frag := range strings.Split(path, ".") {
+		if current.children == nil {
+			current.children = make(map[string]*projection)
+		}
+		next, ok := current.children[frag]
+		if !ok {
+			next = &projection{}
+			current.children[frag] = next
+		}
+		current = next
+	}
+	current.all = true
+}
+
+// readCompound reads the payload of a compound into the given compound, decoding only
+// the tags that are part of this projection, and skipping all others.
+func (p *projection) readCompound(compound *Compound, rd io.Reader, bo binary.ByteOrder) error {
+	if p.all {
+		return compound.ReadFrom(rd, bo)
+	}
+
+	if err := enter(rd); err != nil {
+		return err
+	}
+	defer leave(rd)
+
+	compound.Value = make(map[string]Tag)
+	compound.keys = nil
+	for {
+		idByte, err := readByte(rd, bo)
+		if err != nil {
+			return fail(rd, fmt.Errorf("read ID: %w", unexpectedEOF(err)))
+		}
+		id := ID(idByte)
+		if id == IDTagEnd {
+			return nil
+		}
+		if id >= NumIDTags {
+			return fail(rd, fmt.Errorf("unknown tag ID %s", id))
+		}
+		name, err := readString(rd, bo)
+		if err != nil {
+			return fail(rd, fmt.Errorf("read tag name: %w", unexpectedEOF(err)))
+		}
+
+		pushPath(rd, name)
+		child, ok := p.children[name]
+		if !ok || (!child.all && id != IDTagCompound) {
+			if err := skipPayload(rd, bo, id); err != nil {
+				return fmt.Errorf("skip %s with name '%s': %w", id, name, fail(rd, unexpectedEOF(err)))
+			}
+			popPath(rd)
+			continue
+		}
+
+		tag, err := newTagFromID(id)
+		if err != nil {
+			return fail(rd, err)
+		}
+		tag.SetName(name)
+		if id == IDTagCompound {
+			err = child.readCompound(tag.(*Compound), rd, bo)
+		} else {
+			err = tag.ReadFrom(rd, bo)
+		}
+		if err != nil {
+			return fmt.Errorf("read %s with name '%s': %w", id, name, fail(rd, unexpectedEOF(err)))
+		}
+		popPath(rd)
+		compound.Put(tag)
+	}
+}
@@ -0,0 +1,103 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestProjectionSuite(t *testing.T) {
+	suite.Run(t, new(ProjectionSuite))
+}
+
+type ProjectionSuite struct {
+	suite.Suite
+}
+
+func (suite *ProjectionSuite) chunk() Tag {
+	return NewCompoundTag("", []Tag{
+		NewIntTag("DataVersion", 2586),
+		NewCompoundTag("Level", []Tag{
+			NewIntTag("xPos", -3),
+			NewListTag("Sections", []Tag{
+				NewCompoundTag("", []Tag{
+					NewByteTag("Y", 0),
+					NewLongArrayTag("BlockStates", []int64{1, 2, 3}),
+					NewListTag("Palette", []Tag{
+						NewCompoundTag("", []Tag{NewStringTag("Name", "minecraft:air")}),
+					}, IDTagCompound),
+				}),
+			}, IDTagCompound),
+			NewStringTag("Status", "full"),
+			NewCompoundTag("Heightmaps", []Tag{
+				NewLongArrayTag("WORLD_SURFACE", []int64{4, 5}),
+			}),
+		}),
+		NewLongTag("InhabitedTime", 1234),
+	})
+}
+
+func (suite *ProjectionSuite) TestProject() {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian, NetworkLittleEndian} {
+		var buf bytes.Buffer
+		suite.NoError(NewEncoder(&buf, order).WriteTag(suite.chunk()))
+		suite.NoError(NewEncoder(&buf, order).WriteTag(NewStringTag("next", "tag")))
+
+		dec := NewDecoder(&buf, order, Project("Level.xPos", "Level.Status", "InhabitedTime", "Level.Heightmaps", "Level.Missing.Path", "xPos.Foo"))
+		tag, err := dec.ReadTag()
+		suite.NoError(err)
+		suite.Equal(ToString(NewCompoundTag("", []Tag{
+			NewCompoundTag("Level", []Tag{
+				NewIntTag("xPos", -3),
+				NewStringTag("Status", "full"),
+				NewCompoundTag("Heightmaps", []Tag{
+					NewLongArrayTag("WORLD_SURFACE", []int64{4, 5}),
+				}),
+			}),
+			NewLongTag("InhabitedTime", 1234),
+		})), ToString(tag), order)
+
+		// the decoder must be positioned right after the projected tag
+		next, err := dec.ReadTag()
+		suite.NoError(err)
+		suite.Equal(ToString(NewStringTag("next", "tag")), ToString(next))
+	}
+}
+
+func (suite *ProjectionSuite) TestProject_Root() {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(suite.chunk()))
+	tag, err := NewDecoder(&buf, binary.BigEndian, Project("")).ReadTag()
+	suite.NoError(err)
+	suite.Equal(ToString(suite.chunk()), ToString(tag))
+}
+
+func (suite *ProjectionSuite) TestProject_Unmarshal() {
+	type chunk struct {
+		InhabitedTime int64
+		Level         struct {
+			XPos   int32  `nbt:"xPos"`
+			Status string `nbt:"Status"`
+		}
+	}
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(suite.chunk()))
+	var got chunk
+	suite.NoError(UnmarshalReader(&buf, binary.BigEndian, &got, Project("Level.xPos", "Level.Status", "InhabitedTime")))
+	suite.Equal(int64(1234), got.InhabitedTime)
+	suite.Equal(int32(-3), got.Level.XPos)
+	suite.Equal("full", got.Level.Status)
+}
+
+func (suite *ProjectionSuite) TestProject_Truncated() {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(suite.chunk()))
+	data := buf.Bytes()
+	for i := 0; i < len(data); i++ {
+		_, err := NewDecoder(bytes.NewReader(data[:i]), binary.BigEndian, Project("Level.Status")).ReadTag()
+		suite.Error(err, "truncated at %d", i)
+	}
+}
@@ -0,0 +1,399 @@
+package region
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// The game compresses LZ4 chunks with lz4-java's LZ4BlockOutputStream. This is not
+// the LZ4 frame format, but a sequence of blocks, each of which has the following
+// header, followed by the (possibly compressed) block data.
+//
+//	magic            [8]byte  "LZ4Block"
+//	token            byte     compression method | compression level
+//	compressedLen    int32    little endian
+//	decompressedLen  int32    little endian
+//	checksum         int32    little endian, xxHash32 of the decompressed data
+//
+// The stream is terminated by an empty block.
+
+const (
+	lz4BlockHeaderSize = 21
+	lz4MethodRaw       = 0x10
+	lz4MethodLZ4       = 0x20
+	lz4LevelBase       = 10
+	lz4BlockSize       = 1 << 16
+	lz4ChecksumSeed    = 0x9747b28c
+	// lz4ChecksumMask is applied to the checksum, since lz4-java only
+	// uses the lower 28 bits of the hash.
+	lz4ChecksumMask = 0x0fffffff
+
+	lz4MinMatch     = 4
+	lz4LastLiterals = 5  // the last 5 bytes of a block are always literals
+	lz4MFLimit      = 12 // the last match must start at least 12 bytes before the end
+	lz4HashLog      = 16
+	lz4MaxOffset    = 0xffff
+)
+
+var lz4Magic = []byte("LZ4Block")
+
+var errLZ4Corrupt = errors.New("corrupt lz4 data")
+
+// lz4Reader decompresses an LZ4Block stream.
+type lz4Reader struct {
+	rd     io.Reader
+	header []byte
+	buf    []byte
+	data   []byte // decompressed, but not yet read data
+	done   bool
+}
+
+func newLZ4Reader(rd io.Reader) io.ReadCloser {
+	return &lz4Reader{
+		rd:     rd,
+		header: make([]byte, lz4BlockHeaderSize),
+	}
+}
+
+func (r *lz4Reader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.readBlock(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (r *lz4Reader) readBlock() error {
+	if _, err := io.ReadFull(r.rd, r.header); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF // the stream must be terminated by an empty block
+		}
+		return fmt.Errorf("read lz4 block header: %w", err)
+	}
+	if !bytes.Equal(r.header[:len(lz4Magic)], lz4Magic) {
+		return fmt.Errorf("lz4 block magic: %w", errLZ4Corrupt)
+	}
+
+	token := r.header[8]
+	method := token & 0xf0
+	blockSize := 1 << (lz4LevelBase + int(token&0x0f))
+	compressedLen := int(int32(binary.LittleEndian.Uint32(r.header[9:])))
+	decompressedLen := int(int32(binary.LittleEndian.Uint32(r.header[13:])))
+	checksum := binary.LittleEndian.Uint32(r.header[17:])
+	if decompressedLen < 0 || decompressedLen > blockSize ||
+		compressedLen < 0 || compressedLen > lz4MaxCompressedLen(blockSize) ||
+		(method == lz4MethodRaw && compressedLen != decompressedLen) ||
+		(method != lz4MethodRaw && method != lz4MethodLZ4) {
+		return fmt.Errorf("lz4 block header: %w", errLZ4Corrupt)
+	}
+	if decompressedLen == 0 {
+		if compressedLen != 0 || checksum != 0 {
+			return fmt.Errorf("lz4 end block: %w", errLZ4Corrupt)
+		}
+		r.done = true
+		return nil
+	}
+
+	if cap(r.buf) < compressedLen {
+		r.buf = make([]byte, compressedLen)
+	}
+	compressed := r.buf[:compressedLen]
+	if _, err := io.ReadFull(r.rd, compressed); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("read lz4 block: %w", err)
+	}
+
+	data := compressed
+	if method == lz4MethodLZ4 {
+		var err error
+		data, err = lz4DecompressBlock(compressed, decompressedLen)
+		if err != nil {
+			return err
+		}
+		if len(data) != decompressedLen {
+			return fmt.Errorf("lz4 block length: %w", errLZ4Corrupt)
+		}
+	}
+	if xxHash32(data, lz4ChecksumSeed)&lz4ChecksumMask != checksum {
+		return fmt.Errorf("lz4 block checksum: %w", errLZ4Corrupt)
+	}
+	r.data = data
+	return nil
+}
+
+func (r *lz4Reader) Close() error {
+	return nil
+}
+
+// lz4Writer compresses data into an LZ4Block stream. Close must be called to
+// write the last block and the terminating empty block.
+type lz4Writer struct {
+	w   io.Writer
+	buf []byte
+}
+
+func newLZ4Writer(w io.Writer) io.WriteCloser {
+	return &lz4Writer{
+		w:   w,
+		buf: make([]byte, 0, lz4BlockSize),
+	}
+}
+
+func (w *lz4Writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flushBlock(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (w *lz4Writer) flushBlock() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	method := byte(lz4MethodLZ4)
+	data := lz4CompressBlock(w.buf)
+	if len(data) >= len(w.buf) {
+		method, data = lz4MethodRaw, w.buf
+	}
+	if err := w.writeBlock(method, data, len(w.buf), xxHash32(w.buf, lz4ChecksumSeed)&lz4ChecksumMask); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+func (w *lz4Writer) writeBlock(method byte, data []byte, decompressedLen int, checksum uint32) error {
+	header := make([]byte, lz4BlockHeaderSize)
+	copy(header, lz4Magic)
+	header[8] = method | byte(bits.Len(lz4BlockSize-1)-lz4LevelBase)
+	binary.LittleEndian.PutUint32(header[9:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(header[13:], uint32(decompressedLen))
+	binary.LittleEndian.PutUint32(header[17:], checksum)
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data)
+	return err
+}
+
+func (w *lz4Writer) Close() error {
+	if err := w.flushBlock(); err != nil {
+		return err
+	}
+	return w.writeBlock(lz4MethodRaw, nil, 0, 0)
+}
+
+// lz4DecompressBlock decompresses a single LZ4 block, whose decompressed
+// data must not be longer than the given size.
+func lz4DecompressBlock(src []byte, size int) ([]byte, error) {
+	dst := make([]byte, 0, size)
+
+	readLength := func(i, length int) (int, int, error) {
+		if length != 15 {
+			return i, length, nil
+		}
+		for {
+			if i >= len(src) {
+				return 0, 0, errLZ4Corrupt
+			}
+			b := src[i]
+			i++
+			length += int(b)
+			if b != 0xff {
+				return i, length, nil
+			}
+		}
+	}
+
+	var literals, matchLen int
+	var err error
+	for i := 0; ; {
+		if i >= len(src) {
+			return nil, errLZ4Corrupt
+		}
+		token := src[i]
+		i++
+
+		i, literals, err = readLength(i, int(token>>4))
+		if err != nil {
+			return nil, err
+		}
+		if literals > len(src)-i || literals > size-len(dst) {
+			return nil, errLZ4Corrupt
+		}
+		dst = append(dst, src[i:i+literals]...)
+		i += literals
+		if i == len(src) {
+			return dst, nil // the last sequence only consists of literals
+		}
+
+		if i+2 > len(src) {
+			return nil, errLZ4Corrupt
+		}
+		offset := int(binary.LittleEndian.Uint16(src[i:]))
+		i += 2
+		if offset == 0 || offset > len(dst) {
+			return nil, errLZ4Corrupt
+		}
+
+		i, matchLen, err = readLength(i, int(token&0x0f))
+		if err != nil {
+			return nil, err
+		}
+		matchLen += lz4MinMatch
+		if matchLen > size-len(dst) {
+			return nil, errLZ4Corrupt
+		}
+		start := len(dst) - offset
+		for j := 0; j < matchLen; j++ { // byte by byte, since the match may overlap
+			dst = append(dst, dst[start+j])
+		}
+	}
+}
+
+// lz4CompressBlock compresses the given data into a single LZ4 block, using
+// a greedy search with a hash table of previously seen positions.
+func lz4CompressBlock(src []byte) []byte {
+	dst := make([]byte, 0, lz4MaxCompressedLen(len(src)))
+	anchor := 0
+
+	if len(src) > lz4MFLimit {
+		var table [1 << lz4HashLog]int32 // position + 1, so 0 means empty
+		for i := 0; i <= len(src)-lz4MFLimit; {
+			seq := binary.LittleEndian.Uint32(src[i:])
+			h := (seq * 2654435761) >> (32 - lz4HashLog)
+			ref := int(table[h]) - 1
+			table[h] = int32(i + 1)
+			if ref < 0 || i-ref > lz4MaxOffset || binary.LittleEndian.Uint32(src[ref:]) != seq {
+				i++
+				continue
+			}
+
+			end := i + lz4MinMatch
+			for end < len(src)-lz4LastLiterals && src[end] == src[ref+end-i] {
+				end++
+			}
+			dst = lz4AppendSequence(dst, src[anchor:i], i-ref, end-i)
+			i = end
+			anchor = i
+		}
+	}
+
+	return lz4AppendSequence(dst, src[anchor:], 0, 0)
+}
+
+// lz4MaxCompressedLen returns the worst case length of an LZ4 block with n bytes of
+// decompressed data, which is reached if the data can't be compressed at all.
+func lz4MaxCompressedLen(n int) int {
+	return n + n/255 + 16
+}
+
+// lz4AppendSequence appends a sequence of the given literals and a match with
+// the given offset and length to dst. A match length of 0 omits the match,
+// which is only valid for the last sequence of a block.
+func lz4AppendSequence(dst, literals []byte, offset, matchLen int) []byte {
+	appendLength := func(dst []byte, length int) []byte {
+		for length -= 15; length >= 0xff; length -= 0xff {
+			dst = append(dst, 0xff)
+		}
+		return append(dst, byte(length))
+	}
+
+	token := byte(0)
+	if len(literals) >= 15 {
+		token = 15 << 4
+	} else {
+		token = byte(len(literals)) << 4
+	}
+	if matchLen > 0 {
+		if matchLen-lz4MinMatch >= 15 {
+			token |= 15
+		} else {
+			token |= byte(matchLen - lz4MinMatch)
+		}
+	}
+
+	dst = append(dst, token)
+	if len(literals) >= 15 {
+		dst = appendLength(dst, len(literals))
+	}
+	dst = append(dst, literals...)
+	if matchLen > 0 {
+		dst = append(dst, byte(offset), byte(offset>>8))
+		if matchLen-lz4MinMatch >= 15 {
+			dst = appendLength(dst, matchLen-lz4MinMatch)
+		}
+	}
+	return dst
+}
+
+// xxHash32 computes the 32 bit xxHash of the given data with the given seed.
+func xxHash32(data []byte, seed uint32) uint32 {
+	const (
+		prime1 uint32 = 2654435761
+		prime2 uint32 = 2246822519
+		prime3 uint32 = 3266489917
+		prime4 uint32 = 668265263
+		prime5 uint32 = 374761393
+	)
+	round := func(acc, lane uint32) uint32 {
+		return bits.RotateLeft32(acc+lane*prime2, 13) * prime1
+	}
+
+	n := len(data)
+	var h uint32
+	if n >= 16 {
+		v1 := seed + prime1 + prime2
+		v2 := seed + prime2
+		v3 := seed
+		v4 := seed - prime1
+		for ; len(data) >= 16; data = data[16:] {
+			v1 = round(v1, binary.LittleEndian.Uint32(data[0:]))
+			v2 = round(v2, binary.LittleEndian.Uint32(data[4:]))
+			v3 = round(v3, binary.LittleEndian.Uint32(data[8:]))
+			v4 = round(v4, binary.LittleEndian.Uint32(data[12:]))
+		}
+		h = bits.RotateLeft32(v1, 1) + bits.RotateLeft32(v2, 7) + bits.RotateLeft32(v3, 12) + bits.RotateLeft32(v4, 18)
+	} else {
+		h = seed + prime5
+	}
+
+	h += uint32(n)
+	for ; len(data) >= 4; data = data[4:] {
+		h += binary.LittleEndian.Uint32(data) * prime3
+		h = bits.RotateLeft32(h, 17) * prime4
+	}
+	for _, b := range data {
+		h += uint32(b) * prime5
+		h = bits.RotateLeft32(h, 11) * prime1
+	}
+
+	h ^= h >> 15
+	h *= prime2
+	h ^= h >> 13
+	h *= prime3
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,144 @@
+package region
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsatke/nbt"
+)
+
+func Test_xxHash32(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint32
+	}{
+		{"", 0x02cc5d05},
+		{"abc", 0x32d153ff},
+		{"Nobody inspects the spammish repetition", 0xe2293b2f},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, xxHash32([]byte(tt.in), 0), tt.in)
+	}
+}
+
+func Test_lz4DecompressBlock(t *testing.T) {
+	// block produced by the reference lz4 implementation
+	block := []byte{
+		0x3f, 0x61, 0x62, 0x63, 0x03, 0x00, 0x0e, 0x6f, 0x20, 0x68, 0x65, 0x6c,
+		0x6c, 0x6f, 0x06, 0x00, 0x06, 0x50, 0x77, 0x6f, 0x72, 0x6c, 0x64,
+	}
+	want := "abcabcabcabcabcabcabcabcabcabcabcabc hello hello hello hello hello world"
+
+	got, err := lz4DecompressBlock(block, len(want))
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	_, err = lz4DecompressBlock(block, len(want)-1)
+	assert.ErrorIs(t, err, errLZ4Corrupt)
+	_, err = lz4DecompressBlock(block[:10], len(want))
+	assert.ErrorIs(t, err, errLZ4Corrupt)
+}
+
+func Test_lz4Stream(t *testing.T) {
+	random := make([]byte, 3*lz4BlockSize+17)
+	rand.New(rand.NewSource(1)).Read(random)
+	repetitive := bytes.Repeat([]byte("minecraft:stone minecraft:dirt "), 10000)
+
+	for name, data := range map[string][]byte{
+		"empty":      {},
+		"short":      []byte("hello"),
+		"random":     random,
+		"repetitive": repetitive,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newLZ4Writer(&buf)
+			_, err := w.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			if name == "repetitive" {
+				assert.Less(t, buf.Len(), len(data)/10)
+			}
+
+			got, err := ioutil.ReadAll(newLZ4Reader(&buf))
+			require.NoError(t, err)
+			assert.Equal(t, len(data), len(got))
+			assert.True(t, bytes.Equal(data, got))
+		})
+	}
+}
+
+// testdata/lz4block.bin is an LZ4Block stream framed like lz4-java's
+// LZ4BlockOutputStream frames it with its default block size of 64KiB, which is
+// what the game uses. It was not created by this package: the first block contains
+// 64KiB of text, compressed by the reference lz4 command line tool, the second one
+// "hello world", which is stored raw, since it can't be compressed. The stream is
+// terminated by an empty raw block.
+func Test_lz4Reader_Fixture(t *testing.T) {
+	stream, err := ioutil.ReadFile("testdata/lz4block.bin")
+	require.NoError(t, err)
+	text := bytes.Repeat([]byte("minecraft:stone minecraft:dirt "), 3000)[:lz4BlockSize]
+	want := append(text, "hello world"...)
+
+	got, err := ioutil.ReadAll(newLZ4Reader(bytes.NewReader(stream)))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(want, got))
+
+	// the writer must produce the same framing, even though its compressed
+	// data differs
+	var buf bytes.Buffer
+	w := newLZ4Writer(&buf)
+	_, err = w.Write(want)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	written := buf.Bytes()
+	assert.Equal(t, stream[:9], written[:9], "magic and token")
+	assert.Equal(t, stream[13:lz4BlockHeaderSize], written[13:lz4BlockHeaderSize], "length and checksum")
+	assert.Equal(t, stream[len(stream)-2*lz4BlockHeaderSize-11:], written[len(written)-2*lz4BlockHeaderSize-11:], "last blocks")
+}
+
+func Test_lz4Reader_Checksum(t *testing.T) {
+	var buf bytes.Buffer
+	w := newLZ4Writer(&buf)
+	_, err := w.Write([]byte("some chunk data"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	data[lz4BlockHeaderSize] ^= 0xff // corrupt the first byte of the block data
+	_, err = ioutil.ReadAll(newLZ4Reader(bytes.NewReader(data)))
+	assert.ErrorIs(t, err, errLZ4Corrupt)
+}
+
+func Test_lz4Reader_OversizedBlock(t *testing.T) {
+	header := make([]byte, lz4BlockHeaderSize)
+	copy(header, lz4Magic)
+	header[8] = lz4MethodLZ4 | 6 // 64KiB blocks
+	binary.LittleEndian.PutUint32(header[9:], 1<<31-1)
+	binary.LittleEndian.PutUint32(header[13:], 1)
+
+	// the reader must fail before allocating a buffer for the block data
+	_, err := ioutil.ReadAll(newLZ4Reader(bytes.NewReader(header)))
+	assert.ErrorIs(t, err, errLZ4Corrupt)
+}
+
+func (suite *RegionSuite) TestWriteChunk_LZ4() {
+	reg := suite.create("r.0.0.mca")
+	defer func() { _ = reg.Close() }()
+
+	large := suite.sizedChunk(40)
+	suite.NoError(reg.WriteChunk(0, 0, suite.chunkTag(0, 0), CompressionLZ4))
+	suite.NoError(reg.WriteChunk(1, 0, large, CompressionLZ4))
+
+	chunk, err := reg.ReadChunk(0, 0)
+	suite.NoError(err)
+	suite.Equal(nbt.ToString(suite.chunkTag(0, 0)), nbt.ToString(chunk))
+	chunk, err = reg.ReadChunk(1, 0)
+	suite.NoError(err)
+	suite.Equal(nbt.ToString(large), nbt.ToString(chunk))
+}
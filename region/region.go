@@ -0,0 +1,365 @@
+// Package region provides read and write access to chunks stored in Anvil region files (r.X.Z.mca).
+//
+// A region file stores up to 32x32 chunks. It starts with an 8KiB header, consisting
+// of a location table and a timestamp table, followed by the chunk data, which is
+// aligned to 4KiB sectors. Every chunk is prefixed with its length and the type of
+// compression that was used to compress the NBT data of the chunk.
+//
+//	reg, _ := region.Open(afero.NewOsFs(), "world/region/r.0.0.mca")
+//	defer reg.Close()
+//	for _, info := range reg.Chunks() {
+//		chunk, _ := reg.ReadChunk(info.X, info.Z)
+//		fmt.Println(nbt.ToString(chunk))
+//	}
+//
+// Chunks that are too large to fit into a region file are stored in separate
+// c.X.Z.mcc files next to the region file, which is handled transparently. This
+// requires the region file to be named r.X.Z.mca, since the name of the external
+// file is derived from the absolute chunk coordinates.
+//
+// To modify a region, open it with OpenFile and use WriteChunk.
+//
+//	reg, _ := region.OpenFile(afero.NewOsFs(), "world/region/r.0.0.mca", os.O_RDWR|os.O_CREATE, 0644)
+//	defer reg.Close()
+//	_ = reg.WriteChunk(0, 0, chunk, region.CompressionZlib)
+package region
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/tsatke/nbt"
+)
+
+const (
+	// SectorSize is the size of a sector in a region file. Chunk data is
+	// always aligned to sectors.
+	SectorSize = 4096
+	// Width is the amount of chunks along each axis of a region.
+	Width = 32
+	// NumChunks is the amount of chunks a region can hold.
+	NumChunks = Width * Width
+
+	headerSectors    = 2
+	chunkHeaderSize  = 5 // 4 bytes length, 1 byte compression type
+	timestampsOffset = NumChunks * 4
+	maxSectorCount   = 0xff
+	maxSectorOffset  = 0xffffff
+
+	// externalFlag is set on the compression type of a chunk that is
+	// stored in an external .mcc file.
+	externalFlag = 0x80
+)
+
+// CompressionType is the compression type of a chunk, as stored in the
+// chunk header in the region file.
+type CompressionType byte
+
+const (
+	// CompressionGzip indicates a gzip compressed chunk. This is not used by
+	// the game, but supported.
+	CompressionGzip CompressionType = 1
+	// CompressionZlib indicates a zlib compressed chunk. This is the default.
+	CompressionZlib CompressionType = 2
+	// CompressionNone indicates an uncompressed chunk.
+	CompressionNone CompressionType = 3
+	// CompressionLZ4 indicates an LZ4 compressed chunk, which can be enabled
+	// with region-file-compression in the server.properties.
+	CompressionLZ4 CompressionType = 4
+)
+
+func (c CompressionType) String() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZlib:
+		return "zlib"
+	case CompressionNone:
+		return "none"
+	case CompressionLZ4:
+		return "lz4"
+	}
+	return fmt.Sprintf("CompressionType(%d)", byte(c))
+}
+
+var (
+	// ErrChunkNotPresent is returned when a chunk is requested that is not
+	// stored in the region.
+	ErrChunkNotPresent = errors.New("chunk not present")
+	// ErrOutOfBounds is returned when local chunk coordinates are not
+	// within [0,32).
+	ErrOutOfBounds = errors.New("chunk coordinates out of bounds")
+	// ErrChunkTooLarge is returned when a chunk does not fit into the
+	// maximum amount of sectors that a chunk may occupy.
+	ErrChunkTooLarge = errors.New("chunk too large")
+	// ErrRegionFull is returned when no more sectors can be addressed
+	// in the region file.
+	ErrRegionFull = errors.New("region full")
+	// ErrUnknownPosition is returned when an external chunk file has to be
+	// accessed, but the region file name is not of the form r.X.Z.mca.
+	ErrUnknownPosition = errors.New("unknown region position")
+)
+
+// ChunkInfo describes a chunk that is present in a region file.
+type ChunkInfo struct {
+	// X and Z are the local coordinates of the chunk within the region,
+	// in the range [0,32).
+	X, Z int
+	// Timestamp is the last modification time of the chunk.
+	Timestamp time.Time
+	// SectorOffset is the index of the first sector of the chunk data.
+	SectorOffset int
+	// SectorCount is the amount of sectors that are reserved for the chunk.
+	SectorCount int
+}
+
+// Region is an opened region file. A Region is not safe for concurrent use.
+type Region struct {
+	fs   afero.Fs
+	dir  string
+	file afero.File
+
+	// x and z are the region coordinates, as parsed from the file name.
+	x, z       int
+	positioned bool
+
+	locations  [NumChunks]uint32
+	timestamps [NumChunks]uint32
+}
+
+// Open opens the region file with the given name in the given file system
+// for reading.
+func Open(fs afero.Fs, name string) (*Region, error) {
+	return OpenFile(fs, name, os.O_RDONLY, 0)
+}
+
+// OpenFile opens the region file with the given name in the given file system
+// with the given flags, just as os.OpenFile. If the opened file is empty and
+// writable, for example because it was just created, an empty header is written
+// to it.
+func OpenFile(fs afero.Fs, name string, flag int, perm os.FileMode) (*Region, error) {
+	file, err := fs.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Region{
+		fs:   fs,
+		dir:  filepath.Dir(name),
+		file: file,
+	}
+	_, err = fmt.Sscanf(filepath.Base(name), "r.%d.%d.mca", &r.x, &r.z)
+	r.positioned = err == nil
+	if err := r.init(flag&(os.O_WRONLY|os.O_RDWR) != 0); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close closes the underlying region file.
+func (r *Region) Close() error {
+	return r.file.Close()
+}
+
+func (r *Region) init(writable bool) error {
+	if writable {
+		stat, err := r.file.Stat()
+		if err != nil {
+			return fmt.Errorf("stat: %w", err)
+		}
+		if stat.Size() == 0 {
+			if _, err := r.file.WriteAt(make([]byte, headerSectors*SectorSize), 0); err != nil {
+				return fmt.Errorf("write header: %w", err)
+			}
+			if err := r.file.Sync(); err != nil {
+				return fmt.Errorf("sync: %w", err)
+			}
+			return nil
+		}
+	}
+	return r.readHeader()
+}
+
+func (r *Region) readHeader() error {
+	header := make([]byte, headerSectors*SectorSize)
+	n, err := r.file.ReadAt(header, 0)
+	if n < len(header) && (err == nil || errors.Is(err, io.EOF)) {
+		return fmt.Errorf("read header: file too small: %w", io.ErrUnexpectedEOF)
+	} else if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("read header: %w", err)
+	}
+	for i := 0; i < NumChunks; i++ {
+		r.locations[i] = binary.BigEndian.Uint32(header[i*4:])
+		r.timestamps[i] = binary.BigEndian.Uint32(header[timestampsOffset+i*4:])
+	}
+	return nil
+}
+
+// Chunks returns information about all chunks that are present in this region,
+// ordered by their index in the location table.
+func (r *Region) Chunks() []ChunkInfo {
+	var infos []ChunkInfo
+	for i := 0; i < NumChunks; i++ {
+		if info, ok := r.info(i); ok {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// Chunk returns information about the chunk at the given local coordinates,
+// or false if the chunk is not present.
+func (r *Region) Chunk(x, z int) (ChunkInfo, bool) {
+	index, err := chunkIndex(x, z)
+	if err != nil {
+		return ChunkInfo{}, false
+	}
+	return r.info(index)
+}
+
+func (r *Region) info(index int) (ChunkInfo, bool) {
+	location := r.locations[index]
+	if location == 0 {
+		return ChunkInfo{}, false
+	}
+	return ChunkInfo{
+		X:            index % Width,
+		Z:            index / Width,
+		Timestamp:    time.Unix(int64(r.timestamps[index]), 0),
+		SectorOffset: int(location >> 8),
+		SectorCount:  int(location & 0xff),
+	}, true
+}
+
+// ChunkReader returns a reader that yields the decompressed NBT data of the chunk
+// at the given local coordinates. The returned reader must be closed after use.
+// If the chunk is not present, ErrChunkNotPresent is returned.
+func (r *Region) ChunkReader(x, z int) (io.ReadCloser, error) {
+	index, err := chunkIndex(x, z)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := r.info(index)
+	if !ok {
+		return nil, ErrChunkNotPresent
+	}
+
+	data, compression, err := r.chunkData(info)
+	if err != nil {
+		return nil, fmt.Errorf("chunk %d,%d: %w", x, z, err)
+	}
+	rd, err := decompress(data, compression)
+	if err != nil {
+		_ = data.Close()
+		return nil, fmt.Errorf("chunk %d,%d: %w", x, z, err)
+	}
+	return readCloser{rd, data}, nil
+}
+
+// ReadChunk reads and decodes the chunk at the given local coordinates.
+// If the chunk is not present, ErrChunkNotPresent is returned.
+func (r *Region) ReadChunk(x, z int) (*nbt.Compound, error) {
+	rd, err := r.ChunkReader(x, z)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rd.Close() }()
+
+	tag, err := nbt.NewDecoder(rd, binary.BigEndian).ReadTag()
+	if err != nil {
+		return nil, fmt.Errorf("chunk %d,%d: %w", x, z, err)
+	}
+	compound, ok := tag.(*nbt.Compound)
+	if !ok {
+		return nil, fmt.Errorf("chunk %d,%d: root tag is %s, not a compound", x, z, tag.ID())
+	}
+	return compound, nil
+}
+
+// chunkData returns a reader for the still compressed chunk data of the
+// given chunk, together with its compression type. If the chunk is stored
+// in an external file, the returned reader reads from that file.
+func (r *Region) chunkData(info ChunkInfo) (io.ReadCloser, CompressionType, error) {
+	if info.SectorOffset < headerSectors {
+		return nil, 0, fmt.Errorf("sector offset %d points into the header", info.SectorOffset)
+	}
+
+	offset := int64(info.SectorOffset) * SectorSize
+	header := make([]byte, chunkHeaderSize)
+	if _, err := r.file.ReadAt(header, offset); err != nil {
+		return nil, 0, fmt.Errorf("read chunk header: %w", err)
+	}
+	length := int64(binary.BigEndian.Uint32(header))
+	compression := CompressionType(header[4])
+	if length < 1 || length > int64(info.SectorCount)*SectorSize-4 {
+		return nil, 0, fmt.Errorf("chunk length %d does not fit into %d sectors", length, info.SectorCount)
+	}
+
+	if compression&externalFlag != 0 {
+		name, err := r.externalName(info.X, info.Z)
+		if err != nil {
+			return nil, 0, err
+		}
+		file, err := r.fs.Open(name)
+		if err != nil {
+			return nil, 0, fmt.Errorf("open external chunk: %w", err)
+		}
+		return file, compression &^ externalFlag, nil
+	}
+	return ioutil.NopCloser(io.NewSectionReader(r.file, offset+chunkHeaderSize, length-1)), compression, nil
+}
+
+// externalName returns the name of the external .mcc file for the chunk at the
+// given local coordinates. The name is based on the absolute chunk coordinates.
+func (r *Region) externalName(x, z int) (string, error) {
+	if !r.positioned {
+		return "", ErrUnknownPosition
+	}
+	return filepath.Join(r.dir, fmt.Sprintf("c.%d.%d.mcc", r.x*Width+x, r.z*Width+z)), nil
+}
+
+func decompress(rd io.Reader, compression CompressionType) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		return gzip.NewReader(rd)
+	case CompressionZlib:
+		return zlib.NewReader(rd)
+	case CompressionNone:
+		return ioutil.NopCloser(rd), nil
+	case CompressionLZ4:
+		return newLZ4Reader(rd), nil
+	}
+	return nil, fmt.Errorf("unsupported compression type %s", compression)
+}
+
+// readCloser reads from a decompressing reader, and closes both the
+// decompressing reader and the underlying source.
+type readCloser struct {
+	io.ReadCloser
+	source io.Closer
+}
+
+func (rc readCloser) Close() error {
+	err := rc.ReadCloser.Close()
+	if sourceErr := rc.source.Close(); err == nil {
+		err = sourceErr
+	}
+	return err
+}
+
+func chunkIndex(x, z int) (int, error) {
+	if x < 0 || x >= Width || z < 0 || z >= Width {
+		return 0, fmt.Errorf("%d,%d: %w", x, z, ErrOutOfBounds)
+	}
+	return x + z*Width, nil
+}
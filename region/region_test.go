@@ -0,0 +1,156 @@
+package region
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/nbt"
+)
+
+func TestRegionSuite(t *testing.T) {
+	suite.Run(t, new(RegionSuite))
+}
+
+type RegionSuite struct {
+	suite.Suite
+
+	fs afero.Fs
+}
+
+func (suite *RegionSuite) SetupTest() {
+	suite.fs = afero.NewMemMapFs()
+}
+
+func (suite *RegionSuite) chunkTag(x, z int32) *nbt.Compound {
+	return nbt.NewCompoundTag("", []nbt.Tag{
+		nbt.NewIntTag("xPos", x),
+		nbt.NewIntTag("zPos", z),
+		nbt.NewStringTag("Status", "minecraft:full"),
+	})
+}
+
+func (suite *RegionSuite) compress(tag nbt.Tag, compression CompressionType) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch compression {
+	case CompressionGzip:
+		w = gzip.NewWriter(&buf)
+	case CompressionZlib:
+		w = zlib.NewWriter(&buf)
+	default:
+		w = nopWriteCloser{&buf}
+	}
+	suite.Require().NoError(nbt.NewEncoder(w, binary.BigEndian).WriteTag(tag))
+	suite.Require().NoError(w.Close())
+	return buf.Bytes()
+}
+
+type rawChunk struct {
+	x, z        int
+	timestamp   uint32
+	compression CompressionType
+	data        []byte
+}
+
+// writeRegion assembles a region file by hand, placing the chunks one after
+// another directly behind the header.
+func (suite *RegionSuite) writeRegion(name string, chunks ...rawChunk) {
+	header := make([]byte, headerSectors*SectorSize)
+	var body []byte
+	sector := headerSectors
+	for _, chunk := range chunks {
+		index := chunk.x + chunk.z*Width
+		payload := make([]byte, chunkHeaderSize+len(chunk.data))
+		binary.BigEndian.PutUint32(payload, uint32(len(chunk.data)+1))
+		payload[4] = byte(chunk.compression)
+		copy(payload[chunkHeaderSize:], chunk.data)
+		count := (len(payload) + SectorSize - 1) / SectorSize
+		payload = append(payload, make([]byte, count*SectorSize-len(payload))...)
+
+		binary.BigEndian.PutUint32(header[index*4:], uint32(sector<<8|count))
+		binary.BigEndian.PutUint32(header[timestampsOffset+index*4:], chunk.timestamp)
+		body = append(body, payload...)
+		sector += count
+	}
+	suite.Require().NoError(afero.WriteFile(suite.fs, name, append(header, body...), 0644))
+}
+
+func (suite *RegionSuite) TestReadChunk() {
+	suite.writeRegion("r.0.0.mca",
+		rawChunk{0, 0, 100, CompressionZlib, suite.compress(suite.chunkTag(0, 0), CompressionZlib)},
+		rawChunk{5, 3, 200, CompressionGzip, suite.compress(suite.chunkTag(5, 3), CompressionGzip)},
+		rawChunk{31, 31, 300, CompressionNone, suite.compress(suite.chunkTag(31, 31), CompressionNone)},
+	)
+
+	reg, err := Open(suite.fs, "r.0.0.mca")
+	suite.Require().NoError(err)
+	defer func() { _ = reg.Close() }()
+
+	for _, pos := range [][2]int{{0, 0}, {5, 3}, {31, 31}} {
+		chunk, err := reg.ReadChunk(pos[0], pos[1])
+		suite.NoError(err)
+		suite.Equal(nbt.ToString(suite.chunkTag(int32(pos[0]), int32(pos[1]))), nbt.ToString(chunk))
+	}
+}
+
+func (suite *RegionSuite) TestChunks() {
+	suite.writeRegion("r.0.0.mca",
+		rawChunk{5, 3, 200, CompressionZlib, suite.compress(suite.chunkTag(5, 3), CompressionZlib)},
+		rawChunk{1, 0, 100, CompressionZlib, suite.compress(suite.chunkTag(1, 0), CompressionZlib)},
+	)
+
+	reg, err := Open(suite.fs, "r.0.0.mca")
+	suite.Require().NoError(err)
+	defer func() { _ = reg.Close() }()
+
+	suite.Equal([]ChunkInfo{
+		{X: 1, Z: 0, Timestamp: time.Unix(100, 0), SectorOffset: 3, SectorCount: 1},
+		{X: 5, Z: 3, Timestamp: time.Unix(200, 0), SectorOffset: 2, SectorCount: 1},
+	}, reg.Chunks())
+
+	_, ok := reg.Chunk(0, 0)
+	suite.False(ok)
+}
+
+func (suite *RegionSuite) TestChunkReader() {
+	data := suite.compress(suite.chunkTag(2, 2), CompressionNone)
+	suite.writeRegion("r.0.0.mca", rawChunk{2, 2, 0, CompressionNone, data})
+
+	reg, err := Open(suite.fs, "r.0.0.mca")
+	suite.Require().NoError(err)
+	defer func() { _ = reg.Close() }()
+
+	rd, err := reg.ChunkReader(2, 2)
+	suite.Require().NoError(err)
+	got, err := ioutil.ReadAll(rd)
+	suite.NoError(err)
+	suite.NoError(rd.Close())
+	suite.Equal(data, got)
+}
+
+func (suite *RegionSuite) TestReadChunk_Errors() {
+	suite.writeRegion("r.0.0.mca")
+
+	reg, err := Open(suite.fs, "r.0.0.mca")
+	suite.Require().NoError(err)
+	defer func() { _ = reg.Close() }()
+
+	_, err = reg.ReadChunk(0, 0)
+	suite.ErrorIs(err, ErrChunkNotPresent)
+	_, err = reg.ReadChunk(32, 0)
+	suite.ErrorIs(err, ErrOutOfBounds)
+}
+
+func (suite *RegionSuite) TestOpen_TooSmall() {
+	suite.Require().NoError(afero.WriteFile(suite.fs, "r.0.0.mca", make([]byte, 100), 0644))
+	_, err := Open(suite.fs, "r.0.0.mca")
+	suite.ErrorIs(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,256 @@
+package region
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tsatke/nbt"
+)
+
+// WriteChunk encodes the given tag, compresses it with the given compression type
+// and stores it as the chunk at the given local coordinates, replacing any chunk
+// that was stored there before.
+//
+// The chunk data is always written to sectors that are not in use, and the file is
+// synced before the header is updated to point to the new data. If the process
+// crashes while writing, the region still contains either the old or the new
+// chunk, but never a partially written one. Sectors that were previously occupied
+// by the chunk are reused by later writes.
+//
+// Chunks that don't fit into the maximum of 255 sectors (about 1MiB) are written
+// to an external c.X.Z.mcc file, and only a reference is stored in the region.
+// A stale external file is removed once the chunk fits into the region again.
+func (r *Region) WriteChunk(x, z int, tag nbt.Tag, compression CompressionType) error {
+	index, err := chunkIndex(x, z)
+	if err != nil {
+		return err
+	}
+
+	payload, err := encodeChunk(tag, compression)
+	if err != nil {
+		return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+	}
+
+	external := sectorCount(len(payload)) > maxSectorCount
+	if external {
+		if err := r.writeExternal(x, z, payload[chunkHeaderSize:]); err != nil {
+			return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+		}
+		payload = []byte{0, 0, 0, 1, byte(compression) | externalFlag}
+	}
+
+	if err := r.writeChunkData(index, payload); err != nil {
+		return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+	}
+	if !external {
+		if err := r.removeExternal(x, z); err != nil {
+			return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+		}
+	}
+	return nil
+}
+
+// DeleteChunk removes the chunk at the given local coordinates from the region.
+// The sectors that were occupied by the chunk are reused by later writes.
+// Deleting a chunk that is not present is a noop.
+func (r *Region) DeleteChunk(x, z int) error {
+	index, err := chunkIndex(x, z)
+	if err != nil {
+		return err
+	}
+	if r.locations[index] == 0 {
+		return nil
+	}
+	if err := r.writeHeaderEntry(index, 0, 0); err != nil {
+		return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+	}
+	if err := r.removeExternal(x, z); err != nil {
+		return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+	}
+	return nil
+}
+
+// writeExternal writes the given compressed chunk data to the external file
+// of the chunk at the given local coordinates. The data is written to a
+// temporary file first, which then replaces the external file.
+func (r *Region) writeExternal(x, z int, data []byte) error {
+	name, err := r.externalName(x, z)
+	if err != nil {
+		return err
+	}
+
+	tmpName := name + ".tmp"
+	file, err := r.fs.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("create external chunk: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write external chunk: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("sync external chunk: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close external chunk: %w", err)
+	}
+	if err := r.fs.Rename(tmpName, name); err != nil {
+		return fmt.Errorf("rename external chunk: %w", err)
+	}
+	return nil
+}
+
+// removeExternal removes the external file of the chunk at the given local
+// coordinates, if it exists.
+func (r *Region) removeExternal(x, z int) error {
+	if !r.positioned {
+		return nil // there can't be any external chunk files
+	}
+	name, err := r.externalName(x, z)
+	if err != nil {
+		return err
+	}
+	if err := r.fs.Remove(name); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove external chunk: %w", err)
+	}
+	return nil
+}
+
+// encodeChunk encodes and compresses the given tag, and returns the chunk
+// payload, including the 5 byte chunk header.
+func encodeChunk(tag nbt.Tag, compression CompressionType) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, chunkHeaderSize)) // reserve space for the chunk header
+
+	var w io.WriteCloser
+	switch compression {
+	case CompressionGzip:
+		w = gzip.NewWriter(&buf)
+	case CompressionZlib:
+		w = zlib.NewWriter(&buf)
+	case CompressionNone:
+		w = nopWriteCloser{&buf}
+	case CompressionLZ4:
+		w = newLZ4Writer(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported compression type %s", compression)
+	}
+	if err := nbt.NewEncoder(w, binary.BigEndian).WriteTag(tag); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("%s: %w", compression, err)
+	}
+
+	payload := buf.Bytes()
+	binary.BigEndian.PutUint32(payload, uint32(len(payload)-4))
+	payload[4] = byte(compression)
+	return payload, nil
+}
+
+// writeChunkData writes the given chunk payload into free sectors and
+// then points the header entry of the chunk with the given index to it.
+func (r *Region) writeChunkData(index int, payload []byte) error {
+	count := sectorCount(len(payload))
+	if count > maxSectorCount {
+		return fmt.Errorf("%d sectors: %w", count, ErrChunkTooLarge)
+	}
+
+	offset, err := r.allocate(count)
+	if err != nil {
+		return err
+	}
+
+	sectors := make([]byte, count*SectorSize)
+	copy(sectors, payload)
+	if _, err := r.file.WriteAt(sectors, int64(offset)*SectorSize); err != nil {
+		return fmt.Errorf("write data: %w", err)
+	}
+	if err := r.file.Sync(); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	return r.writeHeaderEntry(index, uint32(offset)<<8|uint32(count), uint32(time.Now().Unix()))
+}
+
+// writeHeaderEntry writes the location and the timestamp of the chunk with the
+// given index to the header, and syncs the file. The location is written last,
+// so a crash in between at most leaves a newer timestamp on the old chunk data.
+func (r *Region) writeHeaderEntry(index int, location, timestamp uint32) error {
+	buf := make([]byte, 4)
+
+	binary.BigEndian.PutUint32(buf, timestamp)
+	if _, err := r.file.WriteAt(buf, int64(timestampsOffset+index*4)); err != nil {
+		return fmt.Errorf("write timestamp: %w", err)
+	}
+	binary.BigEndian.PutUint32(buf, location)
+	if _, err := r.file.WriteAt(buf, int64(index*4)); err != nil {
+		return fmt.Errorf("write location: %w", err)
+	}
+	if err := r.file.Sync(); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	r.locations[index] = location
+	r.timestamps[index] = timestamp
+	return nil
+}
+
+// allocate finds the first run of the given amount of consecutive sectors that are
+// not used by the header or any chunk, and returns the offset of the first sector
+// of the run. If there is no such run, the sectors are allocated at the end of the
+// file. Sectors of the chunk that is about to be replaced are still considered in
+// use, since the header still points to them.
+func (r *Region) allocate(count int) (int, error) {
+	var used []bool
+	mark := func(offset, count int) {
+		for len(used) < offset+count {
+			used = append(used, false)
+		}
+		for i := offset; i < offset+count; i++ {
+			used[i] = true
+		}
+	}
+
+	mark(0, headerSectors)
+	for _, location := range r.locations {
+		if location != 0 {
+			mark(int(location>>8), int(location&0xff))
+		}
+	}
+
+	run := 0
+	for i, inUse := range used {
+		if inUse {
+			run = 0
+			continue
+		}
+		run++
+		if run == count {
+			return i - count + 1, nil
+		}
+	}
+
+	offset := len(used) - run // extend the free run at the end of the file, if any
+	if offset > maxSectorOffset {
+		return 0, ErrRegionFull
+	}
+	return offset, nil
+}
+
+func sectorCount(size int) int {
+	return (size + SectorSize - 1) / SectorSize
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
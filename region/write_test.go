@@ -0,0 +1,111 @@
+package region
+
+import (
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/tsatke/nbt"
+)
+
+func (suite *RegionSuite) create(name string) *Region {
+	reg, err := OpenFile(suite.fs, name, os.O_RDWR|os.O_CREATE, 0644)
+	suite.Require().NoError(err)
+	return reg
+}
+
+// sizedChunk returns a chunk tag whose uncompressed encoding spans
+// roughly the given amount of sectors.
+func (suite *RegionSuite) sizedChunk(sectors int) *nbt.Compound {
+	return nbt.NewCompoundTag("", []nbt.Tag{
+		nbt.NewByteArrayTag("data", make([]int8, sectors*SectorSize-SectorSize/2)),
+	})
+}
+
+func (suite *RegionSuite) TestWriteChunk_RoundTrip() {
+	reg := suite.create("r.0.0.mca")
+	suite.NoError(reg.WriteChunk(0, 0, suite.chunkTag(0, 0), CompressionZlib))
+	suite.NoError(reg.WriteChunk(7, 9, suite.chunkTag(7, 9), CompressionGzip))
+	suite.NoError(reg.WriteChunk(31, 31, suite.chunkTag(31, 31), CompressionNone))
+	suite.NoError(reg.Close())
+
+	reg, err := Open(suite.fs, "r.0.0.mca")
+	suite.Require().NoError(err)
+	defer func() { _ = reg.Close() }()
+
+	suite.Len(reg.Chunks(), 3)
+	for _, pos := range [][2]int{{0, 0}, {7, 9}, {31, 31}} {
+		chunk, err := reg.ReadChunk(pos[0], pos[1])
+		suite.NoError(err)
+		suite.Equal(nbt.ToString(suite.chunkTag(int32(pos[0]), int32(pos[1]))), nbt.ToString(chunk))
+	}
+}
+
+func (suite *RegionSuite) TestWriteChunk_ReuseFreedSectors() {
+	reg := suite.create("r.0.0.mca")
+	defer func() { _ = reg.Close() }()
+
+	suite.NoError(reg.WriteChunk(0, 0, suite.sizedChunk(3), CompressionNone))
+	suite.NoError(reg.WriteChunk(1, 0, suite.chunkTag(1, 0), CompressionNone))
+	info, _ := reg.Chunk(0, 0)
+	suite.Equal(ChunkInfo{X: 0, Z: 0, Timestamp: info.Timestamp, SectorOffset: 2, SectorCount: 3}, info)
+
+	// the replacement must not overwrite the old data before the header points to it
+	suite.NoError(reg.WriteChunk(0, 0, suite.chunkTag(0, 0), CompressionNone))
+	info, _ = reg.Chunk(0, 0)
+	suite.Equal(6, info.SectorOffset)
+
+	// the three sectors freed by chunk 0,0 are reused
+	suite.NoError(reg.WriteChunk(2, 0, suite.sizedChunk(2), CompressionNone))
+	info, _ = reg.Chunk(2, 0)
+	suite.Equal(2, info.SectorOffset)
+	suite.Equal(2, info.SectorCount)
+
+	chunk, err := reg.ReadChunk(0, 0)
+	suite.NoError(err)
+	suite.Equal(nbt.ToString(suite.chunkTag(0, 0)), nbt.ToString(chunk))
+}
+
+func (suite *RegionSuite) TestDeleteChunk() {
+	reg := suite.create("r.0.0.mca")
+	defer func() { _ = reg.Close() }()
+
+	suite.NoError(reg.WriteChunk(3, 4, suite.chunkTag(3, 4), CompressionZlib))
+	suite.NoError(reg.DeleteChunk(3, 4))
+	suite.NoError(reg.DeleteChunk(3, 4))
+	_, err := reg.ReadChunk(3, 4)
+	suite.ErrorIs(err, ErrChunkNotPresent)
+	suite.Empty(reg.Chunks())
+}
+
+func (suite *RegionSuite) TestWriteChunk_External() {
+	reg := suite.create("r.1.-1.mca")
+	defer func() { _ = reg.Close() }()
+
+	large := suite.sizedChunk(maxSectorCount + 1)
+	suite.NoError(reg.WriteChunk(2, 3, large, CompressionNone))
+	exists, err := afero.Exists(suite.fs, "c.34.-29.mcc")
+	suite.NoError(err)
+	suite.True(exists)
+	info, _ := reg.Chunk(2, 3)
+	suite.Equal(1, info.SectorCount)
+
+	chunk, err := reg.ReadChunk(2, 3)
+	suite.NoError(err)
+	suite.Equal(nbt.ToString(large), nbt.ToString(chunk))
+
+	// shrinking the chunk moves it back into the region
+	suite.NoError(reg.WriteChunk(2, 3, suite.chunkTag(2, 3), CompressionZlib))
+	exists, err = afero.Exists(suite.fs, "c.34.-29.mcc")
+	suite.NoError(err)
+	suite.False(exists)
+	chunk, err = reg.ReadChunk(2, 3)
+	suite.NoError(err)
+	suite.Equal(nbt.ToString(suite.chunkTag(2, 3)), nbt.ToString(chunk))
+}
+
+func (suite *RegionSuite) TestWriteChunk_ExternalUnknownPosition() {
+	reg := suite.create("region.mca")
+	defer func() { _ = reg.Close() }()
+
+	suite.ErrorIs(reg.WriteChunk(0, 0, suite.sizedChunk(maxSectorCount+1), CompressionNone), ErrUnknownPosition)
+}
@@ -0,0 +1,454 @@
+package nbt
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SNBTSyntaxError is returned by ParseSNBT if the input is not valid SNBT.
+// Line and column are 1-based, the column is counted in characters.
+type SNBTSyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SNBTSyntaxError) Error() string {
+	return fmt.Sprintf("snbt: line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+var (
+	// the patterns are the same as the game uses, and are matched against lower case values
+	snbtDoublePattern   = regexp.MustCompile(`^[-+]?(?:[0-9]+[.]|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?$`)
+	snbtSuffixedPattern = regexp.MustCompile(`^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?[fd]$`)
+	snbtIntegerPattern  = regexp.MustCompile(`^[-+]?(?:0|[1-9][0-9]*)[bslBSL]?$`)
+)
+
+// ParseSNBT parses the given stringified NBT, as used in commands and data packs,
+// such as {Count:1b,id:"minecraft:stone",tag:{Damage:0}}, into a tag. The returned
+// root tag has an empty name.
+//
+// Numbers are typed by their suffix: b for Byte, s for Short, L for Long, f for Float
+// and d for Double. Integers without suffix are Ints, decimals without suffix are
+// Doubles. true and false are Bytes with value 1 and 0. Typed arrays are written as
+// [B;1b,2b], [I;1,2] and [L;1L,2L]. Like in the game, unquoted values that are not
+// valid numbers, for example because they are out of range, are Strings.
+//
+// If the input is invalid, a *SNBTSyntaxError is returned.
+func ParseSNBT(snbt string) (Tag, error) {
+	p := &snbtParser{in: snbt}
+	tag, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipWhitespace()
+	if p.pos < len(p.in) {
+		return nil, p.errorf("unexpected trailing data")
+	}
+	return tag, nil
+}
+
+type snbtParser struct {
+	in  string
+	pos int
+}
+
+// errorf creates a syntax error at the current position.
+func (p *snbtParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+func (p *snbtParser) errorAt(pos int, format string, args ...interface{}) error {
+	consumed := p.in[:pos]
+	line := strings.Count(consumed, "\n") + 1
+	column := utf8.RuneCountInString(consumed[strings.LastIndex(consumed, "\n")+1:]) + 1
+	return &SNBTSyntaxError{
+		Line:   line,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (p *snbtParser) skipWhitespace() {
+	for p.pos < len(p.in) {
+		switch p.in[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek skips whitespace and returns the next byte without consuming it,
+// or 0 if the end of the input was reached.
+func (p *snbtParser) peek() byte {
+	p.skipWhitespace()
+	if p.pos >= len(p.in) {
+		return 0
+	}
+	return p.in[p.pos]
+}
+
+func (p *snbtParser) expect(c byte) error {
+	if next := p.peek(); next != c {
+		return p.unexpected(fmt.Sprintf("'%c'", c))
+	}
+	p.pos++
+	return nil
+}
+
+func (p *snbtParser) unexpected(expected string) error {
+	if p.pos >= len(p.in) {
+		return p.errorf("expected %s, but reached end of input", expected)
+	}
+	r, _ := utf8.DecodeRuneInString(p.in[p.pos:])
+	return p.errorf("expected %s, but got '%c'", expected, r)
+}
+
+func (p *snbtParser) parseValue() (Tag, error) {
+	switch p.peek() {
+	case '{':
+		return p.parseCompound()
+	case '[':
+		return p.parseListOrArray()
+	case '"', '\'':
+		s, err := p.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return NewStringTag("", s), nil
+	}
+
+	s := p.parseUnquoted()
+	if s == "" {
+		return nil, p.unexpected("value")
+	}
+	return typeUnquoted(s), nil
+}
+
+func (p *snbtParser) parseCompound() (Tag, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	compound := NewCompoundTag("", nil)
+	if p.peek() == '}' {
+		p.pos++
+		return compound, nil
+	}
+
+	for {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		value.SetName(key) // like in the game, duplicate keys overwrite previous values
+		compound.Put(value)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return compound, nil
+		default:
+			return nil, p.unexpected("',' or '}'")
+		}
+	}
+}
+
+func (p *snbtParser) parseKey() (string, error) {
+	switch p.peek() {
+	case '"', '\'':
+		return p.parseQuoted()
+	}
+	key := p.parseUnquoted()
+	if key == "" {
+		return "", p.unexpected("key")
+	}
+	return key, nil
+}
+
+func (p *snbtParser) parseListOrArray() (Tag, error) {
+	if err := p.expect('['); err != nil {
+		return nil, err
+	}
+	if p.pos+1 < len(p.in) && p.in[p.pos+1] == ';' {
+		switch p.in[p.pos] {
+		case 'B', 'I', 'L':
+			typ := p.in[p.pos]
+			p.pos += 2
+			return p.parseArray(typ)
+		default:
+			return nil, p.errorf("invalid array type '%c', expected B, I or L", p.in[p.pos])
+		}
+	}
+
+	list := NewListTag("", nil, IDTagEnd)
+	if p.peek() == ']' {
+		p.pos++
+		return list, nil
+	}
+	for {
+		p.skipWhitespace()
+		elemPos := p.pos
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if len(list.Value) == 0 {
+			list.ListType = value.ID()
+		} else if value.ID() != list.ListType {
+			return nil, p.errorAt(elemPos, "can't insert %s into list of %s", value.ID(), list.ListType)
+		}
+		list.Value = append(list.Value, value)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return list, nil
+		default:
+			return nil, p.unexpected("',' or ']'")
+		}
+	}
+}
+
+// parseArray parses the elements of a typed array, after the type prefix
+// has already been consumed.
+func (p *snbtParser) parseArray(typ byte) (Tag, error) {
+	var elemID ID
+	var bits int
+	switch typ {
+	case 'B':
+		elemID, bits = IDTagByte, 8
+	case 'I':
+		elemID, bits = IDTagInt, 32
+	default:
+		elemID, bits = IDTagLong, 64
+	}
+
+	var values []int64
+	if p.peek() == ']' {
+		p.pos++
+	} else {
+		for {
+			p.skipWhitespace()
+			elemPos := p.pos
+			s := p.parseUnquoted()
+			if s == "" {
+				return nil, p.unexpected("number")
+			}
+			val, err := parseArrayElement(s, elemID, bits)
+			if err != nil {
+				return nil, p.errorAt(elemPos, "can't insert '%s' into %s array: %s", s, elemID, err)
+			}
+			values = append(values, val)
+
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			if err := p.expect(']'); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	switch elemID {
+	case IDTagByte:
+		arr := make([]int8, len(values))
+		for i, v := range values {
+			arr[i] = int8(v)
+		}
+		return NewByteArrayTag("", arr), nil
+	case IDTagInt:
+		arr := make([]int32, len(values))
+		for i, v := range values {
+			arr[i] = int32(v)
+		}
+		return NewIntArrayTag("", arr), nil
+	default:
+		return NewLongArrayTag("", values), nil
+	}
+}
+
+// parseArrayElement parses an integer array element. Elements must either have
+// the suffix of the array type, or no suffix at all.
+func parseArrayElement(s string, elemID ID, bits int) (int64, error) {
+	if !snbtIntegerPattern.MatchString(s) {
+		return 0, fmt.Errorf("not an integer")
+	}
+	digits := s
+	switch last := s[len(s)-1]; last {
+	case 'b', 'B', 's', 'S', 'l', 'L':
+		if suffix := unicodeLower(last); !(suffix == 'b' && elemID == IDTagByte || suffix == 'l' && elemID == IDTagLong) {
+			return 0, fmt.Errorf("wrong type suffix '%c'", last)
+		}
+		digits = s[:len(s)-1]
+	}
+	val, err := strconv.ParseInt(digits, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("out of range")
+	}
+	return val, nil
+}
+
+func unicodeLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func isUnquotedChar(c byte) bool {
+	return c >= '0' && c <= '9' ||
+		c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c == '_' || c == '-' || c == '.' || c == '+'
+}
+
+func (p *snbtParser) parseUnquoted() string {
+	p.skipWhitespace()
+	start := p.pos
+	for p.pos < len(p.in) && isUnquotedChar(p.in[p.pos]) {
+		p.pos++
+	}
+	return p.in[start:p.pos]
+}
+
+func (p *snbtParser) parseQuoted() (string, error) {
+	quote := p.peek()
+	p.pos++
+
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.in) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.in[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *snbtParser) parseEscape(sb *strings.Builder) error {
+	escapePos := p.pos
+	p.pos++ // backslash
+	if p.pos >= len(p.in) {
+		return p.errorf("unterminated string")
+	}
+	c := p.in[p.pos]
+	p.pos++
+	switch c {
+	case '\\', '"', '\'':
+		sb.WriteByte(c)
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 's':
+		sb.WriteByte(' ')
+	case 'u':
+		if p.pos+4 > len(p.in) {
+			return p.errorAt(escapePos, "invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.in[p.pos:p.pos+4], 16, 16)
+		if err != nil {
+			return p.errorAt(escapePos, "invalid unicode escape")
+		}
+		p.pos += 4
+		sb.WriteRune(rune(r))
+	default:
+		return p.errorAt(escapePos, "invalid escape sequence '\\%c'", c)
+	}
+	return nil
+}
+
+// typeUnquoted converts an unquoted value into a tag, based on its format.
+// Values that are no valid numbers or booleans become String tags.
+func typeUnquoted(s string) Tag {
+	switch s {
+	case "true":
+		return NewByteTag("", 1)
+	case "false":
+		return NewByteTag("", 0)
+	}
+
+	lower := strings.ToLower(s)
+	if snbtSuffixedPattern.MatchString(lower) {
+		digits := lower[:len(lower)-1]
+		if lower[len(lower)-1] == 'f' {
+			if f, err := strconv.ParseFloat(digits, 32); err == nil && !math.IsInf(f, 0) {
+				return NewFloatTag("", float32(f))
+			}
+		} else if f, err := strconv.ParseFloat(digits, 64); err == nil && !math.IsInf(f, 0) {
+			return NewDoubleTag("", f)
+		}
+		return NewStringTag("", s)
+	}
+
+	if snbtIntegerPattern.MatchString(s) {
+		digits := s
+		suffix := byte(0)
+		switch last := lower[len(lower)-1]; last {
+		case 'b', 's', 'l':
+			digits, suffix = s[:len(s)-1], last
+		}
+		switch suffix {
+		case 'b':
+			if i, err := strconv.ParseInt(digits, 10, 8); err == nil {
+				return NewByteTag("", int8(i))
+			}
+		case 's':
+			if i, err := strconv.ParseInt(digits, 10, 16); err == nil {
+				return NewShortTag("", int16(i))
+			}
+		case 'l':
+			if i, err := strconv.ParseInt(digits, 10, 64); err == nil {
+				return NewLongTag("", i)
+			}
+		default:
+			if i, err := strconv.ParseInt(digits, 10, 32); err == nil {
+				return NewIntTag("", int32(i))
+			}
+		}
+		return NewStringTag("", s)
+	}
+
+	if snbtDoublePattern.MatchString(lower) {
+		if f, err := strconv.ParseFloat(lower, 64); err == nil && !math.IsInf(f, 0) {
+			return NewDoubleTag("", f)
+		}
+	}
+	return NewStringTag("", s)
+}
@@ -0,0 +1,128 @@
+package nbt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSNBTParserSuite(t *testing.T) {
+	suite.Run(t, new(SNBTParserSuite))
+}
+
+type SNBTParserSuite struct {
+	suite.Suite
+}
+
+func (suite *SNBTParserSuite) expect(expected Tag, snbt string) {
+	tag, err := ParseSNBT(snbt)
+	if suite.NoError(err, snbt) {
+		suite.Equal(ToString(expected), ToString(tag), snbt)
+	}
+}
+
+func (suite *SNBTParserSuite) expectError(line, column int, snbt string) {
+	_, err := ParseSNBT(snbt)
+	var syntaxErr *SNBTSyntaxError
+	if suite.ErrorAs(err, &syntaxErr, snbt) {
+		suite.Equal(line, syntaxErr.Line, "line of %q: %s", snbt, err)
+		suite.Equal(column, syntaxErr.Column, "column of %q: %s", snbt, err)
+	}
+}
+
+func (suite *SNBTParserSuite) TestItem() {
+	suite.expect(NewCompoundTag("", []Tag{
+		NewByteTag("Count", 1),
+		NewStringTag("id", "minecraft:stone"),
+		NewCompoundTag("tag", []Tag{
+			NewIntTag("Damage", 0),
+		}),
+	}), `{Count:1b,id:"minecraft:stone",tag:{Damage:0}}`)
+}
+
+func (suite *SNBTParserSuite) TestNumbers() {
+	suite.expect(NewByteTag("", -5), "-5b")
+	suite.expect(NewByteTag("", 5), "5B")
+	suite.expect(NewShortTag("", 300), "300s")
+	suite.expect(NewIntTag("", 70000), "70000")
+	suite.expect(NewLongTag("", 9223372036854775807), "9223372036854775807L")
+	suite.expect(NewFloatTag("", 1.5), "1.5f")
+	suite.expect(NewFloatTag("", 2), "2F")
+	suite.expect(NewDoubleTag("", 1.5), "1.5d")
+	suite.expect(NewDoubleTag("", 1.5), "1.5")
+	suite.expect(NewDoubleTag("", 0.5), ".5")
+	suite.expect(NewDoubleTag("", 1e10), "1.e10")
+	suite.expect(NewByteTag("", 1), "true")
+	suite.expect(NewByteTag("", 0), "false")
+}
+
+func (suite *SNBTParserSuite) TestUnquotedStrings() {
+	suite.expect(NewStringTag("", "stone"), "stone")
+	suite.expect(NewStringTag("", "300b"), "300b") // out of range for a byte
+	suite.expect(NewStringTag("", "2147483648"), "2147483648")
+	suite.expect(NewStringTag("", "007"), "007")
+	suite.expect(NewStringTag("", "1e5"), "1e5")
+	suite.expect(NewStringTag("", "a.b-c_d+e"), "a.b-c_d+e")
+}
+
+func (suite *SNBTParserSuite) TestQuotedStrings() {
+	suite.expect(NewStringTag("", `say "hi"`), `"say \"hi\""`)
+	suite.expect(NewStringTag("", `it's`), `'it\'s'`)
+	suite.expect(NewStringTag("", `"quoted"`), `'"quoted"'`)
+	suite.expect(NewStringTag("", `back\slash`), `"back\\slash"`)
+	suite.expect(NewStringTag("", "line\nbreak\ttab"), `"line\nbreak\ttab"`)
+	suite.expect(NewStringTag("", "§a☃"), `"§a☃"`)
+}
+
+func (suite *SNBTParserSuite) TestKeys() {
+	suite.expect(NewCompoundTag("", []Tag{
+		NewIntTag("minecraft:key with spaces", 1),
+		NewIntTag("single", 2),
+		NewIntTag("Under_score.dot-dash+plus", 3),
+	}), `{"minecraft:key with spaces": 1, 'single': 2, Under_score.dot-dash+plus: 3}`)
+}
+
+func (suite *SNBTParserSuite) TestLists() {
+	suite.expect(NewListTag("", nil, IDTagEnd), "[]")
+	suite.expect(NewListTag("", []Tag{
+		NewDoubleTag("", 1),
+		NewDoubleTag("", 2.5),
+		NewDoubleTag("", 3),
+	}, IDTagDouble), "[1.0d, 2.5d, 3.0d]")
+	suite.expect(NewListTag("", []Tag{
+		NewCompoundTag("", []Tag{NewStringTag("a", "b")}),
+		NewCompoundTag("", nil),
+	}, IDTagCompound), "[{a:b},{}]")
+	suite.expect(NewListTag("", []Tag{
+		NewListTag("", []Tag{NewIntTag("", 1)}, IDTagInt),
+		NewListTag("", nil, IDTagEnd),
+	}, IDTagList), "[[1],[]]")
+}
+
+func (suite *SNBTParserSuite) TestArrays() {
+	suite.expect(NewByteArrayTag("", []int8{1, -2, 3}), "[B;1b,-2B,3]")
+	suite.expect(NewIntArrayTag("", []int32{1, -2, 3}), "[I; 1, -2, 3]")
+	suite.expect(NewLongArrayTag("", []int64{1, -2, 3}), "[L;1L,-2l,3]")
+	suite.expect(NewIntArrayTag("", []int32{}), "[I;]")
+}
+
+func (suite *SNBTParserSuite) TestWhitespace() {
+	suite.expect(NewCompoundTag("", []Tag{
+		NewListTag("list", []Tag{NewIntTag("", 1)}, IDTagInt),
+	}), " {\n\tlist : [ 1 ]\n} ")
+}
+
+func (suite *SNBTParserSuite) TestErrors() {
+	suite.expectError(1, 1, "")
+	suite.expectError(1, 7, "{a:1b,}")
+	suite.expectError(1, 6, "{a:1 b:2}")
+	suite.expectError(1, 5, "[1b,2s]")
+	suite.expectError(2, 7, "{\n\ta:[I;1b]\n}")
+	suite.expectError(1, 2, "[X;1]")
+	suite.expectError(1, 12, `{"a":"open}`)
+	suite.expectError(1, 3, `"a\qb"`)
+	suite.expectError(1, 6, "{a:1}}")
+	suite.expectError(1, 7, "[B;1b,300]")
+	suite.expectError(1, 2, "{☃:1}")
+	suite.expectError(1, 8, `{"☃":1 x}`) // columns are counted in characters, not bytes
+}
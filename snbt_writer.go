@@ -0,0 +1,259 @@
+package nbt
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatSNBT converts the given tag to compact, single line stringified NBT, that
+// can be used in commands such as /data or /give, and that can be parsed with
+// ParseSNBT. The name of the given tag is not part of the output.
+//
+// An error is returned if the tag or one of its nested tags is nil or an end tag,
+// or if a float or double is NaN or infinite, since SNBT can't represent these.
+func FormatSNBT(tag Tag) (string, error) {
+	w := &snbtWriter{}
+	if err := w.writeTag(tag); err != nil {
+		return "", err
+	}
+	return w.sb.String(), nil
+}
+
+// FormatSNBTIndent works like FormatSNBT, but produces human readable output, in
+// which every entry of a compound, and every element of a list of compounds or
+// lists, starts on a new line, indented by the given indent once per nesting level.
+func FormatSNBTIndent(tag Tag, indent string) (string, error) {
+	w := &snbtWriter{
+		pretty: true,
+		indent: indent,
+	}
+	if err := w.writeTag(tag); err != nil {
+		return "", err
+	}
+	return w.sb.String(), nil
+}
+
+type snbtWriter struct {
+	sb     strings.Builder
+	pretty bool
+	indent string
+	depth  int
+}
+
+func (w *snbtWriter) newline() {
+	if w.pretty {
+		w.sb.WriteByte('\n')
+		w.sb.WriteString(strings.Repeat(w.indent, w.depth))
+	}
+}
+
+// separator writes the separator between two elements, or between a key
+// and its value. In pretty mode, a space is appended.
+func (w *snbtWriter) separator(sep byte) {
+	w.sb.WriteByte(sep)
+	if w.pretty {
+		w.sb.WriteByte(' ')
+	}
+}
+
+func (w *snbtWriter) writeTag(tag Tag) error {
+	if tag == nil {
+		return ErrNilTag
+	}
+
+	switch tag.ID() {
+	case IDTagByte:
+		w.sb.WriteString(strconv.FormatInt(int64(tag.(*Byte).Value), 10))
+		w.sb.WriteByte('b')
+	case IDTagShort:
+		w.sb.WriteString(strconv.FormatInt(int64(tag.(*Short).Value), 10))
+		w.sb.WriteByte('s')
+	case IDTagInt:
+		w.sb.WriteString(strconv.FormatInt(int64(tag.(*Int).Value), 10))
+	case IDTagLong:
+		w.sb.WriteString(strconv.FormatInt(tag.(*Long).Value, 10))
+		w.sb.WriteByte('L')
+	case IDTagFloat:
+		v := float64(tag.(*Float).Value)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("float %v can't be represented in SNBT", v)
+		}
+		w.sb.WriteString(strconv.FormatFloat(v, 'g', -1, 32))
+		w.sb.WriteByte('f')
+	case IDTagDouble:
+		v := tag.(*Double).Value
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("double %v can't be represented in SNBT", v)
+		}
+		w.sb.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		w.sb.WriteByte('d')
+	case IDTagString:
+		w.writeQuoted(tag.(*String).Value)
+	case IDTagByteArray:
+		values := tag.(*ByteArray).Value
+		w.arrayPrefix('B', len(values))
+		for i, v := range values {
+			if i > 0 {
+				w.separator(',')
+			}
+			w.sb.WriteString(strconv.FormatInt(int64(v), 10))
+			w.sb.WriteByte('b')
+		}
+		w.sb.WriteByte(']')
+	case IDTagIntArray:
+		values := tag.(*IntArray).Value
+		w.arrayPrefix('I', len(values))
+		for i, v := range values {
+			if i > 0 {
+				w.separator(',')
+			}
+			w.sb.WriteString(strconv.FormatInt(int64(v), 10))
+		}
+		w.sb.WriteByte(']')
+	case IDTagLongArray:
+		values := tag.(*LongArray).Value
+		w.arrayPrefix('L', len(values))
+		for i, v := range values {
+			if i > 0 {
+				w.separator(',')
+			}
+			w.sb.WriteString(strconv.FormatInt(v, 10))
+			w.sb.WriteByte('L')
+		}
+		w.sb.WriteByte(']')
+	case IDTagList:
+		return w.writeList(tag.(*List))
+	case IDTagCompound:
+		return w.writeCompound(tag.(*Compound))
+	case IDTagEnd:
+		return ErrUnexpectedEnd
+	default:
+		return fmt.Errorf("unknown tag ID %s", tag.ID())
+	}
+	return nil
+}
+
+func (w *snbtWriter) arrayPrefix(typ byte, length int) {
+	w.sb.WriteByte('[')
+	w.sb.WriteByte(typ)
+	w.sb.WriteByte(';')
+	if w.pretty && length > 0 {
+		w.sb.WriteByte(' ')
+	}
+}
+
+func (w *snbtWriter) writeList(list *List) error {
+	w.sb.WriteByte('[')
+	if len(list.Value) == 0 {
+		w.sb.WriteByte(']')
+		return nil
+	}
+
+	// only lists of nested structures are spread over multiple lines
+	multiline := list.ListType == IDTagCompound || list.ListType == IDTagList
+	w.depth++
+	for i, elem := range list.Value {
+		if i > 0 {
+			w.sb.WriteByte(',')
+			if !multiline && w.pretty {
+				w.sb.WriteByte(' ')
+			}
+		}
+		if multiline {
+			w.newline()
+		}
+		if err := w.writeTag(elem); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	w.depth--
+	if multiline {
+		w.newline()
+	}
+	w.sb.WriteByte(']')
+	return nil
+}
+
+func (w *snbtWriter) writeCompound(compound *Compound) error {
+	w.sb.WriteByte('{')
+	if len(compound.Value) == 0 {
+		w.sb.WriteByte('}')
+		return nil
+	}
+
+	w.depth++
+	for i, k := range compound.Keys() {
+		if i > 0 {
+			w.sb.WriteByte(',')
+		}
+		w.newline()
+		w.writeKey(k)
+		w.separator(':')
+		if err := w.writeTag(compound.Value[k]); err != nil {
+			return fmt.Errorf("entry '%s': %w", k, err)
+		}
+	}
+	w.depth--
+	w.newline()
+	w.sb.WriteByte('}')
+	return nil
+}
+
+// writeKey writes the given compound key, quoted only if necessary.
+func (w *snbtWriter) writeKey(key string) {
+	if key == "" {
+		w.writeQuoted(key)
+		return
+	}
+	for i := 0; i < len(key); i++ {
+		if !isUnquotedChar(key[i]) {
+			w.writeQuoted(key)
+			return
+		}
+	}
+	w.sb.WriteString(key)
+}
+
+// writeQuoted writes the given string in quotes. Like the game, it uses double
+// quotes, unless the string contains a double quote before any single quote, in
+// which case single quotes are used, so that fewer escapes are necessary.
+func (w *snbtWriter) writeQuoted(s string) {
+	var quote byte
+	for i := 0; i < len(s) && quote == 0; i++ {
+		switch s[i] {
+		case '"':
+			quote = '\''
+		case '\'':
+			quote = '"'
+		}
+	}
+	if quote == 0 {
+		quote = '"'
+	}
+
+	w.sb.WriteByte(quote)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			w.sb.WriteString(`\\`)
+		case '\n':
+			w.sb.WriteString(`\n`)
+		case '\r':
+			w.sb.WriteString(`\r`)
+		case '\t':
+			w.sb.WriteString(`\t`)
+		case '\b':
+			w.sb.WriteString(`\b`)
+		case '\f':
+			w.sb.WriteString(`\f`)
+		case quote:
+			w.sb.WriteByte('\\')
+			w.sb.WriteByte(c)
+		default:
+			w.sb.WriteByte(c)
+		}
+	}
+	w.sb.WriteByte(quote)
+}
@@ -0,0 +1,160 @@
+package nbt
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSNBTWriterSuite(t *testing.T) {
+	suite.Run(t, new(SNBTWriterSuite))
+}
+
+type SNBTWriterSuite struct {
+	suite.Suite
+}
+
+func (suite *SNBTWriterSuite) item() Tag {
+	return NewCompoundTag("ignored", []Tag{
+		NewByteTag("Count", 1),
+		NewStringTag("id", "minecraft:stone"),
+		NewCompoundTag("tag", []Tag{
+			NewIntTag("Damage", 0),
+			NewListTag("Lore", []Tag{
+				NewStringTag("", `"first"`),
+				NewStringTag("", "it's"),
+			}, IDTagString),
+		}),
+	})
+}
+
+func (suite *SNBTWriterSuite) format(tag Tag) string {
+	snbt, err := FormatSNBT(tag)
+	suite.Require().NoError(err)
+	return snbt
+}
+
+func (suite *SNBTWriterSuite) formatIndent(tag Tag, indent string) string {
+	snbt, err := FormatSNBTIndent(tag, indent)
+	suite.Require().NoError(err)
+	return snbt
+}
+
+func (suite *SNBTWriterSuite) TestFormatSNBT() {
+	suite.Equal(`{Count:1b,id:"minecraft:stone",tag:{Damage:0,Lore:['"first"',"it's"]}}`, suite.format(suite.item()))
+}
+
+func (suite *SNBTWriterSuite) TestFormatSNBTIndent() {
+	suite.Equal(`{
+  Count: 1b,
+  id: "minecraft:stone",
+  tag: {
+    Damage: 0,
+    Lore: ['"first"', "it's"]
+  }
+}`, suite.formatIndent(suite.item(), "  "))
+
+	suite.Equal(`[
+	{
+		a: [I; 1, 2]
+	},
+	{}
+]`, suite.formatIndent(NewListTag("", []Tag{
+		NewCompoundTag("", []Tag{NewIntArrayTag("a", []int32{1, 2})}),
+		NewCompoundTag("", nil),
+	}, IDTagCompound), "\t"))
+}
+
+func (suite *SNBTWriterSuite) TestValues() {
+	for expected, tag := range map[string]Tag{
+		"-5b":                  NewByteTag("", -5),
+		"300s":                 NewShortTag("", 300),
+		"70000":                NewIntTag("", 70000),
+		"9223372036854775807L": NewLongTag("", 9223372036854775807),
+		"1.5f":                 NewFloatTag("", 1.5),
+		"0.1f":                 NewFloatTag("", 0.1),
+		"2d":                   NewDoubleTag("", 2),
+		"1e+100d":              NewDoubleTag("", 1e100),
+		"[B;1b,-2b]":           NewByteArrayTag("", []int8{1, -2}),
+		"[I;]":                 NewIntArrayTag("", nil),
+		"[L;1L,-2L]":           NewLongArrayTag("", []int64{1, -2}),
+		"[]":                   NewListTag("", nil, IDTagEnd),
+		"{}":                   NewCompoundTag("", nil),
+		`"a\\b\nc"`:            NewStringTag("", "a\\b\nc"),
+		`'both"\'quotes'`:      NewStringTag("", `both"'quotes`),
+		`{"":1,"a b":2,"☃":3}`: NewCompoundTag("", []Tag{NewIntTag("", 1), NewIntTag("a b", 2), NewIntTag("☃", 3)}),
+	} {
+		suite.Equal(expected, suite.format(tag))
+	}
+}
+
+func (suite *SNBTWriterSuite) TestRoundTrip() {
+	tag := NewCompoundTag("", []Tag{
+		NewByteTag("byte", -128),
+		NewShortTag("short", -32768),
+		NewIntTag("int", -2147483648),
+		NewLongTag("long", -9223372036854775808),
+		NewFloatTag("float", 0.49823147),
+		NewDoubleTag("double", 0.49312871321823148),
+		NewDoubleTag("big", 1e300),
+		NewStringTag("string", "quotes \" and ' and \\ and \t"),
+		NewStringTag("number-like", "123"),
+		NewByteArrayTag("bytes", []int8{-128, 0, 127}),
+		NewIntArrayTag("ints", []int32{1, 2, 3}),
+		NewLongArrayTag("longs", []int64{1, 2, 3}),
+		NewListTag("compounds", []Tag{
+			NewCompoundTag("", []Tag{NewStringTag("weird key!", "x")}),
+		}, IDTagCompound),
+		NewListTag("empty", nil, IDTagEnd),
+	})
+
+	for _, snbt := range []string{suite.format(tag), suite.formatIndent(tag, "    ")} {
+		parsed, err := ParseSNBT(snbt)
+		suite.NoError(err, snbt)
+		suite.Equal(ToString(tag), ToString(parsed), snbt)
+	}
+}
+
+func (suite *SNBTWriterSuite) TestInvalid() {
+	compoundWithEnd := NewCompoundTag("", nil)
+	compoundWithEnd.Value["end"] = NewEndTag()
+	compoundWithNil := NewCompoundTag("", nil)
+	compoundWithNil.Value["nil"] = nil
+
+	tests := []struct {
+		name string
+		tag  Tag
+		err  error
+	}{
+		{"nil", nil, ErrNilTag},
+		{"end", NewEndTag(), ErrUnexpectedEnd},
+		{"end in compound", compoundWithEnd, ErrUnexpectedEnd},
+		{"nil in compound", compoundWithNil, ErrNilTag},
+		{"nil in list", NewListTag("", []Tag{NewIntTag("", 1), nil}, IDTagInt), ErrNilTag},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			_, err := FormatSNBT(tt.tag)
+			suite.True(errors.Is(err, tt.err), "%v", err)
+			_, err = FormatSNBTIndent(tt.tag, "  ")
+			suite.True(errors.Is(err, tt.err), "%v", err)
+		})
+	}
+}
+
+func (suite *SNBTWriterSuite) TestNonFinite() {
+	for _, tag := range []Tag{
+		NewFloatTag("", float32(math.NaN())),
+		NewFloatTag("", float32(math.Inf(1))),
+		NewDoubleTag("", math.Inf(-1)),
+		NewListTag("", []Tag{NewDoubleTag("", math.NaN())}, IDTagDouble),
+	} {
+		_, err := FormatSNBT(tag)
+		suite.Error(err, ToString(tag))
+	}
+
+	_, err := FormatSNBT(NewCompoundTag("", []Tag{NewDoubleTag("x", math.NaN())}))
+	suite.EqualError(err, "entry 'x': double NaN can't be represented in SNBT")
+}
@@ -1,30 +1,281 @@
 package nbt
 
-import "strings"
+import (
+	"fmt"
+	"math"
+	"strings"
+)
 
 const (
 	structTag          = "nbt"
 	structTagIgnore    = "-"
 	structTagOmitempty = "omitempty"
+
+	// Type overrides, that change the type of the tag that a field is marshalled into,
+	// and the types of the tags that are accepted when unmarshalling, see convertTag.
+	structTagBool      = "bool"
+	structTagByte      = "byte"
+	structTagShort     = "short"
+	structTagInt       = "int"
+	structTagLong      = "long"
+	structTagFloat     = "float"
+	structTagDouble    = "double"
+	structTagByteArray = "bytearray"
+	structTagIntArray  = "intarray"
+	structTagLongArray = "longarray"
+	structTagList      = "list"
 )
 
 type sTag struct {
 	name      string
 	ignore    bool
 	omitempty bool
+	typ       string
 }
 
+// parseStructTag parses the value of an nbt struct tag. Type overrides are only
+// recognized after the first fragment, so that e.g. `nbt:"int"` still names the tag.
 func parseStructTag(in string) (tag sTag) {
 	frags := strings.Split(in, ",")
-	for _, frag := range frags {
-		switch frag {
-		case structTagIgnore:
+	for i, frag := range frags {
+		switch {
+		case frag == structTagIgnore:
 			tag.ignore = true
-		case structTagOmitempty:
+		case frag == structTagOmitempty:
 			tag.omitempty = true
+		case i > 0 && isTypeOverride(frag):
+			tag.typ = frag
 		default:
 			tag.name = frag
 		}
 	}
 	return
 }
+
+func isTypeOverride(frag string) bool {
+	switch frag {
+	case structTagBool, structTagByte, structTagShort, structTagInt, structTagLong,
+		structTagFloat, structTagDouble, structTagByteArray, structTagIntArray,
+		structTagLongArray, structTagList:
+		return true
+	}
+	return false
+}
+
+// convertTag converts the given tag into the type given by a type override of a struct
+// tag, and returns an error if the value of the tag doesn't fit into that type. Byte,
+// short, int and long tags are converted into each other, and into bool, which is a byte
+// tag with the value 0 or 1. Float and double tags are converted into each other, and
+// integer tags into them. Arrays and lists of integer tags are converted into each
+// other. If typ is empty, the tag is returned unchanged.
+func convertTag(tag Tag, typ string) (Tag, error) {
+	switch typ {
+	case "":
+		return tag, nil
+	case structTagBool, structTagByte, structTagShort, structTagInt, structTagLong:
+		v, ok := intValue(tag)
+		if !ok {
+			break
+		}
+		if typ == structTagBool && v != 0 {
+			v = 1
+		}
+		return newIntTag(tag.Name(), typ, v)
+	case structTagFloat, structTagDouble:
+		switch tag := tag.(type) {
+		case *Float:
+			return newFloatTag(tag.Name(), typ, float64(tag.Value), true)
+		case *Double:
+			return newFloatTag(tag.Name(), typ, tag.Value, floatFits(tag.Value, typ))
+		}
+		v, ok := intValue(tag)
+		if !ok {
+			break
+		}
+		f, exact := intToFloat(v, typ)
+		return newFloatTag(tag.Name(), typ, f, exact)
+	case structTagByteArray, structTagIntArray, structTagLongArray:
+		values, ok := intValues(tag)
+		if !ok {
+			break
+		}
+		return newArrayTag(tag.Name(), typ, values)
+	case structTagList:
+		var elems []Tag
+		var elemType ID
+		switch tag := tag.(type) {
+		case *List:
+			return tag, nil
+		case *ByteArray:
+			elemType = IDTagByte
+			for _, v := range tag.Value {
+				elems = append(elems, NewByteTag("", v))
+			}
+		case *IntArray:
+			elemType = IDTagInt
+			for _, v := range tag.Value {
+				elems = append(elems, NewIntTag("", v))
+			}
+		case *LongArray:
+			elemType = IDTagLong
+			for _, v := range tag.Value {
+				elems = append(elems, NewLongTag("", v))
+			}
+		default:
+			return nil, fmt.Errorf("can't convert %s to %s", tag.ID(), typ)
+		}
+		return NewListTag(tag.Name(), elems, elemType), nil
+	}
+	return nil, fmt.Errorf("can't convert %s to %s", tag.ID(), typ)
+}
+
+// intBits returns the size of the integer tag type given by a type override.
+func intBits(typ string) uint {
+	switch typ {
+	case structTagBool, structTagByte, structTagByteArray:
+		return 8
+	case structTagShort:
+		return 16
+	case structTagInt, structTagIntArray:
+		return 32
+	}
+	return 64
+}
+
+func intFitsBits(v int64, bits uint) bool {
+	return bits == 64 || (v >= -1<<(bits-1) && v < 1<<(bits-1))
+}
+
+// newIntTag creates a tag of the integer type given by a type override, and returns
+// an error if the given value doesn't fit into that type.
+func newIntTag(name, typ string, v int64) (Tag, error) {
+	if !intFitsBits(v, intBits(typ)) {
+		return nil, fmt.Errorf("value %d doesn't fit into %s", v, typ)
+	}
+	switch typ {
+	case structTagBool, structTagByte:
+		return NewByteTag(name, int8(v)), nil
+	case structTagShort:
+		return NewShortTag(name, int16(v)), nil
+	case structTagInt:
+		return NewIntTag(name, int32(v)), nil
+	}
+	return NewLongTag(name, v), nil
+}
+
+// newFloatTag creates a tag of the floating point type given by a type override. If
+// exact is false, the value can't be represented exactly by that type, and an error
+// is returned.
+func newFloatTag(name, typ string, v float64, exact bool) (Tag, error) {
+	if !exact {
+		return nil, fmt.Errorf("value %v can't be represented exactly as %s", v, typ)
+	}
+	if typ == structTagFloat {
+		return NewFloatTag(name, float32(v)), nil
+	}
+	return NewDoubleTag(name, v), nil
+}
+
+// floatFits returns whether the given value can be represented exactly by the floating
+// point type given by a type override. NaN and infinite values always fit.
+func floatFits(v float64, typ string) bool {
+	return typ != structTagFloat || float64(float32(v)) == v || math.IsNaN(v)
+}
+
+// intToFloat converts the given integer into the floating point type given by a type
+// override, and returns whether the conversion is exact.
+func intToFloat(v int64, typ string) (float64, bool) {
+	f := float64(v)
+	if typ == structTagFloat {
+		f = float64(float32(f))
+	}
+	if f >= 1<<63 || f < -1<<63 {
+		return f, false
+	}
+	return f, int64(f) == v
+}
+
+// uintToFloat works like intToFloat, but for unsigned integers.
+func uintToFloat(v uint64, typ string) (float64, bool) {
+	f := float64(v)
+	if typ == structTagFloat {
+		f = float64(float32(f))
+	}
+	if f >= 1<<64 {
+		return f, false
+	}
+	return f, uint64(f) == v
+}
+
+// newArrayTag creates an array tag of the type given by a type override, and returns
+// an error if one of the given values doesn't fit into the element type.
+func newArrayTag(name, typ string, values []int64) (Tag, error) {
+	bits := intBits(typ)
+	for i, v := range values {
+		if !intFitsBits(v, bits) {
+			return nil, fmt.Errorf("value %d at index %d doesn't fit into %s", v, i, typ)
+		}
+	}
+	switch typ {
+	case structTagByteArray:
+		conv := make([]int8, len(values))
+		for i, v := range values {
+			conv[i] = int8(v)
+		}
+		return NewByteArrayTag(name, conv), nil
+	case structTagIntArray:
+		conv := make([]int32, len(values))
+		for i, v := range values {
+			conv[i] = int32(v)
+		}
+		return NewIntArrayTag(name, conv), nil
+	}
+	return NewLongArrayTag(name, values), nil
+}
+
+// intValue returns the value of the given byte, short, int or long tag.
+func intValue(tag Tag) (int64, bool) {
+	switch tag := tag.(type) {
+	case *Byte:
+		return int64(tag.Value), true
+	case *Short:
+		return int64(tag.Value), true
+	case *Int:
+		return int64(tag.Value), true
+	case *Long:
+		return tag.Value, true
+	}
+	return 0, false
+}
+
+// intValues returns the values of the given array, or of the given list of byte,
+// short, int or long tags.
+func intValues(tag Tag) ([]int64, bool) {
+	var values []int64
+	switch tag := tag.(type) {
+	case *ByteArray:
+		values = make([]int64, len(tag.Value))
+		for i, v := range tag.Value {
+			values[i] = int64(v)
+		}
+	case *IntArray:
+		values = make([]int64, len(tag.Value))
+		for i, v := range tag.Value {
+			values[i] = int64(v)
+		}
+	case *LongArray:
+		values = append([]int64{}, tag.Value...)
+	case *List:
+		values = make([]int64, len(tag.Value))
+		for i, elem := range tag.Value {
+			v, ok := intValue(elem)
+			if !ok {
+				return nil, false
+			}
+			values[i] = v
+		}
+	default:
+		return nil, false
+	}
+	return values, true
+}
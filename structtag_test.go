@@ -1,6 +1,7 @@
 package nbt
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -55,6 +56,52 @@ func Test_parseStructTag(t *testing.T) {
 				omitempty: true,
 			},
 		},
+		{
+			"type keyword as name",
+			"int",
+			sTag{
+				name: "int",
+			},
+		},
+		{
+			"list keyword as name",
+			"list",
+			sTag{
+				name: "list",
+			},
+		},
+		{
+			"type keyword as name with type",
+			"long,int",
+			sTag{
+				name: "long",
+				typ:  "int",
+			},
+		},
+		{
+			"name type",
+			"Pos,list",
+			sTag{
+				name: "Pos",
+				typ:  "list",
+			},
+		},
+		{
+			"type omitempty",
+			",byte,omitempty",
+			sTag{
+				omitempty: true,
+				typ:       "byte",
+			},
+		},
+		{
+			"name type array",
+			"Flags,bytearray",
+			sTag{
+				name: "Flags",
+				typ:  "bytearray",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -64,3 +111,52 @@ func Test_parseStructTag(t *testing.T) {
 		})
 	}
 }
+
+func Test_convertTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     Tag
+		typ     string
+		want    Tag
+		wantErr bool
+	}{
+		{"none", NewIntTag("a", 5), "", NewIntTag("a", 5), false},
+		{"int to byte", NewIntTag("a", 5), "byte", NewByteTag("a", 5), false},
+		{"long to byte out of range", NewLongTag("a", 0x1ff), "byte", nil, true},
+		{"int to short out of range", NewIntTag("a", -40000), "short", nil, true},
+		{"long to int in range", NewLongTag("a", -1<<31), "int", NewIntTag("a", -1<<31), false},
+		{"byte to long", NewByteTag("a", -1), "long", NewLongTag("a", -1), false},
+		{"short to int", NewShortTag("a", 300), "int", NewIntTag("a", 300), false},
+		{"int to short", NewIntTag("a", 300), "short", NewShortTag("a", 300), false},
+		{"int to bool", NewIntTag("a", 5), "bool", NewByteTag("a", 1), false},
+		{"byte to bool", NewByteTag("a", 0), "bool", NewByteTag("a", 0), false},
+		{"double to float", NewDoubleTag("a", 1.5), "float", NewFloatTag("a", 1.5), false},
+		{"double to float inexact", NewDoubleTag("a", 0.1), "float", nil, true},
+		{"double to float infinite", NewDoubleTag("a", math.Inf(1)), "float", NewFloatTag("a", float32(math.Inf(1))), false},
+		{"int to double", NewIntTag("a", 3), "double", NewDoubleTag("a", 3), false},
+		{"int array to list", NewIntArrayTag("a", []int32{1, 2}), "list", NewListTag("a", []Tag{NewIntTag("", 1), NewIntTag("", 2)}, IDTagInt), false},
+		{"empty byte array to list", NewByteArrayTag("a", nil), "list", NewListTag("a", nil, IDTagByte), false},
+		{"list to byte array", NewListTag("a", []Tag{NewByteTag("", 1), NewByteTag("", -1)}, IDTagByte), "bytearray", NewByteArrayTag("a", []int8{1, -1}), false},
+		{"long array to int array", NewLongArrayTag("a", []int64{1, 2}), "intarray", NewIntArrayTag("a", []int32{1, 2}), false},
+		{"int list to long array", NewListTag("a", []Tag{NewIntTag("", 7)}, IDTagInt), "longarray", NewLongArrayTag("a", []int64{7}), false},
+		{"long to double inexact", NewLongTag("a", 1<<53+1), "double", nil, true},
+		{"int to float inexact", NewIntTag("a", 1<<24+1), "float", nil, true},
+		{"int list to byte array out of range", NewListTag("a", []Tag{NewIntTag("", 1), NewIntTag("", 128)}, IDTagInt), "bytearray", nil, true},
+		{"string to int", NewStringTag("a", "5"), "int", nil, true},
+		{"double to int", NewDoubleTag("a", 5), "int", nil, true},
+		{"string to double", NewStringTag("a", "5"), "double", nil, true},
+		{"string list to int array", NewListTag("a", []Tag{NewStringTag("", "5")}, IDTagString), "intarray", nil, true},
+		{"compound to list", NewCompoundTag("a", nil), "list", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertTag(tt.tag, tt.typ)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && ToString(got) != ToString(tt.want) {
+				t.Errorf("convertTag() = %v, want %v", ToString(got), ToString(tt.want))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package nbt
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -29,25 +30,29 @@ func (t *ByteArray) ID() ID {
 
 // ReadFrom reads a byte array from the reader.
 func (t *ByteArray) ReadFrom(reader io.Reader, order binary.ByteOrder) error {
-	arrLen, err := readUint32(reader, order)
+	arrLen, err := readLength(reader, order)
 	if err != nil {
 		return err
 	}
 
-	buf := make([]byte, arrLen)
-	if err := read(reader, buf); err != nil {
+	// don't trust the length for allocating the buffer, it grows while reading instead
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, reader, int64(arrLen))
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
 		return err
 	}
-	t.Value = make([]int8, arrLen)
-	for i := range buf {
-		t.Value[i] = int8(buf[i])
+	t.Value = make([]int8, n)
+	for i, b := range buf.Bytes() {
+		t.Value[i] = int8(b)
 	}
 	return nil
 }
 
 // WriteTo writes this byte array to the given writer.
 func (t *ByteArray) WriteTo(writer io.Writer, order binary.ByteOrder) error {
-	if err := writeUint32(writer, order, uint32(len(t.Value))); err != nil {
+	if err := writeLength(writer, order, len(t.Value)); err != nil {
 		return fmt.Errorf("write length: %w", err)
 	}
 	buf := make([]byte, len(t.Value))
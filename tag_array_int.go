@@ -29,18 +29,18 @@ func (t *IntArray) ID() ID {
 
 // ReadFrom reads an int array from the given reader.
 func (t *IntArray) ReadFrom(reader io.Reader, order binary.ByteOrder) error {
-	arrLen, err := readUint32(reader, order)
+	arrLen, err := readLength(reader, order)
 	if err != nil {
 		return err
 	}
 
-	buf := make([]int32, arrLen)
-	for i := range buf {
-		val, err := readUint32(reader, order)
+	buf := make([]int32, 0, preallocate(arrLen))
+	for i := uint32(0); i < arrLen; i++ {
+		val, err := readInt32(reader, order)
 		if err != nil {
 			return fmt.Errorf("element %d: %w", i, err)
 		}
-		buf[i] = int32(val)
+		buf = append(buf, val)
 	}
 	t.Value = buf
 	return nil
@@ -48,11 +48,11 @@ func (t *IntArray) ReadFrom(reader io.Reader, order binary.ByteOrder) error {
 
 // WriteTo writes this int array to the given writer.
 func (t *IntArray) WriteTo(writer io.Writer, order binary.ByteOrder) error {
-	if err := writeUint32(writer, order, uint32(len(t.Value))); err != nil {
+	if err := writeLength(writer, order, len(t.Value)); err != nil {
 		return fmt.Errorf("write length: %w", err)
 	}
 	for i, val := range t.Value {
-		if err := writeUint32(writer, order, uint32(val)); err != nil {
+		if err := writeInt32(writer, order, val); err != nil {
 			return fmt.Errorf("element %d: %w", i, err)
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
 )
 
 // NewCompoundTag returns a new Compound tag.
@@ -16,16 +17,26 @@ func NewCompoundTag(name string, val []Tag) *Compound {
 	}
 
 	for _, tag := range val {
-		compound.Value[tag.Name()] = tag
+		compound.Put(tag)
 	}
 
 	return compound
 }
 
 // Compound is a list of named tags.
+//
+// A compound remembers the order in which tags were added with Put, or read from
+// a reader, and writes its tags in that order, so that decoding and encoding
+// a compound results in the same bytes. Tags that are added to Value directly,
+// bypass that order, and are written after all other tags, sorted by name.
 type Compound struct {
 	*tagBase
 	Value map[string]Tag
+
+	// keys are the names of the tags in Value in insertion order. It may contain
+	// names that have since been removed from Value directly, as well as duplicates
+	// of such names, which are handled by Keys.
+	keys []string
 }
 
 // ID returns this tag's id.
@@ -35,7 +46,13 @@ func (t *Compound) ID() ID {
 
 // ReadFrom reads a compound tag from the given reader.
 func (t *Compound) ReadFrom(reader io.Reader, order binary.ByteOrder) error {
+	if err := enter(reader); err != nil {
+		return err
+	}
+	defer leave(reader)
+
 	t.Value = make(map[string]Tag)
+	t.keys = nil
 	decoder := NewDecoder(reader, order)
 
 	for {
@@ -47,15 +64,23 @@ func (t *Compound) ReadFrom(reader io.Reader, order binary.ByteOrder) error {
 			break
 		}
 
-		t.Value[tag.Name()] = tag
+		t.Put(tag)
 	}
 	return nil
 }
 
-// WriteTo writes this tag to the given writer.
+// WriteTo writes this tag to the given writer. The tags are written in the order of
+// Keys, or sorted by name if written by an Encoder with the Canonical option.
 func (t *Compound) WriteTo(writer io.Writer, order binary.ByteOrder) error {
+	tags := t.Tags()
+	if ew, ok := writer.(*encodeWriter); ok && ew.canonical {
+		sort.Slice(tags, func(i, j int) bool {
+			return tags[i].Name() < tags[j].Name()
+		})
+	}
+
 	encoder := NewEncoder(writer, order)
-	for _, tag := range t.Value {
+	for _, tag := range tags {
 		if err := encoder.WriteTag(tag); err != nil {
 			return fmt.Errorf("write tag: %w", err)
 		}
@@ -72,7 +97,70 @@ func (t *Compound) Get(name string) (Tag, bool) {
 	return tag, ok
 }
 
-// Put puts the given named tag into this compound.
+// Put puts the given named tag into this compound. If this compound already contains
+// a tag with the same name, that tag is replaced, and the given tag takes its position.
+// Otherwise, the given tag is added after all other tags.
 func (t *Compound) Put(tag Tag) {
+	if _, ok := t.Value[tag.Name()]; !ok {
+		t.keys = append(t.keys, tag.Name())
+	}
 	t.Value[tag.Name()] = tag
 }
+
+// Delete removes the tag with the given name from this compound. If no such tag
+// exists, this is a no-op.
+func (t *Compound) Delete(name string) {
+	if _, ok := t.Value[name]; !ok {
+		return
+	}
+	delete(t.Value, name)
+	for i := 0; i < len(t.keys); i++ {
+		if t.keys[i] == name {
+			t.keys = append(t.keys[:i], t.keys[i+1:]...)
+			i--
+		}
+	}
+}
+
+// Keys returns the names of all tags in this compound in order. Tags that were added
+// with Put or read from a reader come first, in the order in which they were added.
+// Tags that were added to Value directly come last, sorted by name.
+func (t *Compound) Keys() []string {
+	// if a name occurs more than once, it was removed from Value directly and
+	// added again later, so only its last occurrence counts
+	last := make(map[string]int, len(t.keys))
+	for i, k := range t.keys {
+		last[k] = i
+	}
+
+	keys := make([]string, 0, len(t.Value))
+	seen := make(map[string]bool, len(t.Value))
+	for i, k := range t.keys {
+		if _, ok := t.Value[k]; ok && last[k] == i {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == len(t.Value) {
+		return keys
+	}
+
+	var unordered []string
+	for k := range t.Value {
+		if !seen[k] {
+			unordered = append(unordered, k)
+		}
+	}
+	sort.Strings(unordered)
+	return append(keys, unordered...)
+}
+
+// Tags returns all tags in this compound, in the order of Keys.
+func (t *Compound) Tags() []Tag {
+	keys := t.Keys()
+	tags := make([]Tag, len(keys))
+	for i, k := range keys {
+		tags[i] = t.Value[k]
+	}
+	return tags
+}
@@ -28,15 +28,15 @@ func (t *Int) ID() ID {
 
 // ReadFrom reads an int from the given reader.
 func (t *Int) ReadFrom(reader io.Reader, order binary.ByteOrder) error {
-	val, err := readUint32(reader, order)
+	val, err := readInt32(reader, order)
 	if err != nil {
 		return err
 	}
-	t.Value = int32(val)
+	t.Value = val
 	return nil
 }
 
 // WriteTo writes this int to the given writer.
 func (t *Int) WriteTo(writer io.Writer, order binary.ByteOrder) error {
-	return writeUint32(writer, order, uint32(t.Value))
+	return writeInt32(writer, order, t.Value)
 }
@@ -32,26 +32,36 @@ func (t *List) ID() ID {
 
 // ReadFrom reads a list from the given reader.
 func (t *List) ReadFrom(reader io.Reader, order binary.ByteOrder) error {
+	if err := enter(reader); err != nil {
+		return err
+	}
+	defer leave(reader)
+
 	idByte, err := readByte(reader, order)
 	if err != nil {
-		return fmt.Errorf("read list type: %w", err)
+		return fail(reader, fmt.Errorf("read list type: %w", err))
 	}
 	t.ListType = ID(idByte)
 
-	listLen, err := readUint32(reader, order)
+	listLen, err := readLength(reader, order)
 	if err != nil {
-		return fmt.Errorf("read list length: %w", err)
+		return fail(reader, fmt.Errorf("read list length: %w", err))
+	}
+	if t.ListType >= NumIDTags || (t.ListType == IDTagEnd && listLen > 0) {
+		return fail(reader, fmt.Errorf("%w %s", ErrListType, t.ListType))
 	}
 
-	t.Value = make([]Tag, 0) // MSER-11 remove preallocation
+	t.Value = make([]Tag, 0, preallocate(listLen))
 	for i := uint32(0); i < listLen; i++ {
 		tag, err := newTagFromID(t.ListType)
 		if err != nil {
 			return fmt.Errorf("new tag: %w", err)
 		}
+		pushIndex(reader, i)
 		if err := tag.ReadFrom(reader, order); err != nil {
-			return fmt.Errorf("read tag: %w", err)
+			return fmt.Errorf("read tag: %w", fail(reader, unexpectedEOF(err)))
 		}
+		popPath(reader)
 		t.Value = append(t.Value, tag)
 	}
 
@@ -63,7 +73,7 @@ func (t *List) WriteTo(writer io.Writer, order binary.ByteOrder) error {
 	if err := writeByte(writer, order, byte(t.ListType)); err != nil {
 		return fmt.Errorf("write list type: %w", err)
 	}
-	if err := writeUint32(writer, order, uint32(len(t.Value))); err != nil {
+	if err := writeLength(writer, order, len(t.Value)); err != nil {
 		return fmt.Errorf("write list length: %w", err)
 	}
 	for i, tag := range t.Value {
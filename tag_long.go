@@ -28,15 +28,15 @@ func (t *Long) ID() ID {
 
 // ReadFrom reads a long from the given reader.
 func (t *Long) ReadFrom(reader io.Reader, order binary.ByteOrder) error {
-	val, err := readUint64(reader, order)
+	val, err := readInt64(reader, order)
 	if err != nil {
 		return err
 	}
-	t.Value = int64(val)
+	t.Value = val
 	return nil
 }
 
 // WriteTo writes this long to the given reader.
 func (t *Long) WriteTo(writer io.Writer, order binary.ByteOrder) error {
-	return writeUint64(writer, order, uint64(t.Value))
+	return writeInt64(writer, order, t.Value)
 }
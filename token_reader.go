@@ -0,0 +1,390 @@
+package nbt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind uint8
+
+// Available token kinds.
+const (
+	// TokenValue is a tag that is neither a compound nor a list, i.e. a number,
+	// a string or an array.
+	TokenValue TokenKind = iota
+	// TokenBeginCompound is the start of a compound. It is followed by a token
+	// for every tag in the compound, and a TokenEnd.
+	TokenBeginCompound
+	// TokenBeginList is the start of a list. It is followed by a token for every
+	// element in the list, and a TokenEnd.
+	TokenBeginList
+	// TokenEnd is the end of the compound or list that was started last.
+	TokenEnd
+)
+
+// Token is a single element of the NBT stream, as returned by TokenReader.Token.
+type Token struct {
+	Kind TokenKind
+	// ID is the ID of the tag. It is IDTagEnd for TokenEnd.
+	ID ID
+	// Name is the name of the tag. Elements of a list don't have a name.
+	Name string
+	// ElementType is the type of the elements of a list, for TokenBeginList.
+	ElementType ID
+	// Len is the amount of elements of a list, for TokenBeginList.
+	Len int
+	// Value is the value of the tag for TokenValue. It is an int8, int16, int32,
+	// int64, float32, float64, string, []int8, []int32 or []int64, depending on
+	// the ID.
+	Value interface{}
+}
+
+// TokenReader reads NBT data token by token, without building the tags in memory.
+// Obtain one with NewTokenReader.
+type TokenReader struct {
+	rd       io.Reader
+	bo       binary.ByteOrder
+	nameless bool
+
+	stack []tokenFrame
+}
+
+type tokenFrame struct {
+	list        bool
+	elementType ID
+	remaining   uint32
+}
+
+// NewTokenReader creates a new TokenReader that reads from the given reader
+// and respects the given byte order. The byte order has to be compliant with the
+// byte order of the NBT data in the source.
+func NewTokenReader(source io.Reader, byteOrder binary.ByteOrder, opts ...DecoderOption) *TokenReader {
+	d := &decoder{
+		rd: source,
+		bo: byteOrder,
+	}
+	for _, opt := range opts {
+		opt.applyDecoder(d)
+	}
+	return &TokenReader{
+		rd:       d.reader(),
+		bo:       d.bo,
+		nameless: d.nameless,
+	}
+}
+
+// Token returns the next token. After the root tag is completely read, the next call
+// starts reading the next root tag. If there is no more root tag, io.EOF is returned.
+func (r *TokenReader) Token() (Token, error) {
+	if len(r.stack) == 0 {
+		return r.readRoot()
+	}
+
+	top := &r.stack[len(r.stack)-1]
+	if top.list {
+		if top.remaining == 0 {
+			r.pop()
+			return Token{Kind: TokenEnd}, nil
+		}
+		top.remaining--
+		return r.readPayload(top.elementType, "")
+	}
+
+	id, err := r.readID()
+	if err != nil {
+		return Token{}, err
+	}
+	if id == IDTagEnd {
+		r.pop()
+		return Token{Kind: TokenEnd}, nil
+	}
+	name, err := readString(r.rd, r.bo)
+	if err != nil {
+		return Token{}, fmt.Errorf("read tag name: %w", err)
+	}
+	return r.readPayload(id, name)
+}
+
+// Skip skips the remaining tokens of the compound or list, whose TokenBeginCompound
+// or TokenBeginList was read last, including its TokenEnd, without decoding them.
+func (r *TokenReader) Skip() error {
+	if len(r.stack) == 0 {
+		return errors.New("no compound or list to skip")
+	}
+
+	top := r.stack[len(r.stack)-1]
+	r.pop()
+	if top.list {
+		return skipElements(r.rd, r.bo, top.elementType, top.remaining)
+	}
+	return skipCompound(r.rd, r.bo)
+}
+
+func (r *TokenReader) readRoot() (Token, error) {
+	idByte, err := readByte(r.rd, r.bo)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Token{}, io.EOF
+		}
+		return Token{}, fmt.Errorf("read ID: %w", err)
+	}
+	id := ID(idByte)
+	if id == IDTagEnd || id >= NumIDTags {
+		return Token{}, fmt.Errorf("unexpected root tag ID %s", id)
+	}
+
+	var name string
+	if !r.nameless {
+		name, err = readString(r.rd, r.bo)
+		if err != nil {
+			return Token{}, fmt.Errorf("read tag name: %w", err)
+		}
+	}
+	return r.readPayload(id, name)
+}
+
+func (r *TokenReader) readID() (ID, error) {
+	idByte, err := readByte(r.rd, r.bo)
+	if err != nil {
+		return 0, fmt.Errorf("read ID: %w", unexpectedEOF(err))
+	}
+	if ID(idByte) >= NumIDTags {
+		return 0, fmt.Errorf("unknown tag ID %s", ID(idByte))
+	}
+	return ID(idByte), nil
+}
+
+func (r *TokenReader) readPayload(id ID, name string) (Token, error) {
+	switch id {
+	case IDTagCompound:
+		if err := r.push(tokenFrame{}); err != nil {
+			return Token{}, err
+		}
+		return Token{Kind: TokenBeginCompound, ID: id, Name: name}, nil
+	case IDTagList:
+		elementType, length, err := readListHeader(r.rd, r.bo)
+		if err != nil {
+			return Token{}, err
+		}
+		if err := r.push(tokenFrame{
+			list:        true,
+			elementType: elementType,
+			remaining:   length,
+		}); err != nil {
+			return Token{}, err
+		}
+		return Token{Kind: TokenBeginList, ID: id, Name: name, ElementType: elementType, Len: int(length)}, nil
+	}
+
+	value, err := readValue(r.rd, r.bo, id)
+	if err != nil {
+		return Token{}, fmt.Errorf("read %s with name '%s': %w", id, name, unexpectedEOF(err))
+	}
+	return Token{Kind: TokenValue, ID: id, Name: name, Value: value}, nil
+}
+
+func (r *TokenReader) push(frame tokenFrame) error {
+	if err := enter(r.rd); err != nil {
+		return err
+	}
+	r.stack = append(r.stack, frame)
+	return nil
+}
+
+func (r *TokenReader) pop() {
+	leave(r.rd)
+	r.stack = r.stack[:len(r.stack)-1]
+}
+
+// readListHeader reads the element type and the length of a list.
+func readListHeader(rd io.Reader, bo binary.ByteOrder) (ID, uint32, error) {
+	typ, err := readByte(rd, bo)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read list type: %w", err)
+	}
+	length, err := readLength(rd, bo)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read list length: %w", err)
+	}
+	if ID(typ) >= NumIDTags || (ID(typ) == IDTagEnd && length > 0) {
+		return 0, 0, fmt.Errorf("%w %s", ErrListType, ID(typ))
+	}
+	return ID(typ), length, nil
+}
+
+// readValue reads the payload of a tag that is neither a compound nor a list.
+func readValue(rd io.Reader, bo binary.ByteOrder, id ID) (interface{}, error) {
+	switch id {
+	case IDTagByte:
+		b, err := readByte(rd, bo)
+		return int8(b), err
+	case IDTagShort:
+		i, err := readUint16(rd, bo)
+		return int16(i), err
+	case IDTagInt:
+		return readInt32(rd, bo)
+	case IDTagLong:
+		return readInt64(rd, bo)
+	case IDTagFloat:
+		return readFloat32(rd, bo)
+	case IDTagDouble:
+		return readFloat64(rd, bo)
+	case IDTagString:
+		return readString(rd, bo)
+	case IDTagByteArray, IDTagIntArray, IDTagLongArray:
+		tag, err := newTagFromID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := tag.ReadFrom(rd, bo); err != nil {
+			return nil, err
+		}
+		switch tag := tag.(type) {
+		case *ByteArray:
+			return tag.Value, nil
+		case *IntArray:
+			return tag.Value, nil
+		case *LongArray:
+			return tag.Value, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected tag ID %s", id)
+}
+
+// skipPayload skips the payload of a tag with the given ID, without decoding it.
+func skipPayload(rd io.Reader, bo binary.ByteOrder, id ID) error {
+	switch id {
+	case IDTagEnd:
+		return nil
+	case IDTagByte:
+		return skip(rd, 1)
+	case IDTagShort:
+		return skip(rd, 2)
+	case IDTagFloat:
+		return skip(rd, 4)
+	case IDTagDouble:
+		return skip(rd, 8)
+	case IDTagInt:
+		_, err := readInt32(rd, bo)
+		return err
+	case IDTagLong:
+		_, err := readInt64(rd, bo)
+		return err
+	case IDTagString:
+		length, err := readStringLength(rd, bo)
+		if err != nil {
+			return err
+		}
+		return skip(rd, int64(length))
+	case IDTagByteArray, IDTagIntArray, IDTagLongArray:
+		length, err := readLength(rd, bo)
+		if err != nil {
+			return err
+		}
+		return skipElements(rd, bo, arrayElementType(id), length)
+	case IDTagList, IDTagCompound:
+		if err := enter(rd); err != nil {
+			return err
+		}
+		defer leave(rd)
+
+		if id == IDTagCompound {
+			return skipCompound(rd, bo)
+		}
+		typ, length, err := readListHeader(rd, bo)
+		if err != nil {
+			return err
+		}
+		return skipElements(rd, bo, typ, length)
+	}
+	return fmt.Errorf("unknown tag ID %s", id)
+}
+
+// skipElements skips the given amount of payloads of the given tag ID.
+func skipElements(rd io.Reader, bo binary.ByteOrder, id ID, n uint32) error {
+	if size := fixedPayloadSize(id, bo); size > 0 {
+		return skip(rd, int64(size)*int64(n))
+	}
+	for i := uint32(0); i < n; i++ {
+		if err := skipPayload(rd, bo, id); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// skipCompound skips the remaining tags of a compound, including the end tag.
+func skipCompound(rd io.Reader, bo binary.ByteOrder) error {
+	for {
+		idByte, err := readByte(rd, bo)
+		if err != nil {
+			return fmt.Errorf("read ID: %w", unexpectedEOF(err))
+		}
+		id := ID(idByte)
+		if id == IDTagEnd {
+			return nil
+		}
+		if err := skipPayload(rd, bo, IDTagString); err != nil { // the name
+			return fmt.Errorf("read tag name: %w", err)
+		}
+		if err := skipPayload(rd, bo, id); err != nil {
+			return fmt.Errorf("skip %s: %w", id, err)
+		}
+	}
+}
+
+// fixedPayloadSize returns the size of the payload of the given tag ID, or 0 if
+// the size depends on the payload.
+func fixedPayloadSize(id ID, bo binary.ByteOrder) int {
+	switch id {
+	case IDTagByte:
+		return 1
+	case IDTagShort:
+		return 2
+	case IDTagFloat:
+		return 4
+	case IDTagDouble:
+		return 8
+	case IDTagInt:
+		if !isVarint(bo) {
+			return 4
+		}
+	case IDTagLong:
+		if !isVarint(bo) {
+			return 8
+		}
+	}
+	return 0
+}
+
+func arrayElementType(id ID) ID {
+	switch id {
+	case IDTagByteArray:
+		return IDTagByte
+	case IDTagIntArray:
+		return IDTagInt
+	}
+	return IDTagLong
+}
+
+// skip discards n bytes from the given reader.
+func skip(rd io.Reader, n int64) error {
+	skipped, err := io.CopyN(ioutil.Discard, rd, n)
+	if skipped < n && err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// unexpectedEOF converts io.EOF into io.ErrUnexpectedEOF, for reads that
+// happen in the middle of a tag.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestTokenReaderSuite(t *testing.T) {
+	suite.Run(t, new(TokenReaderSuite))
+}
+
+type TokenReaderSuite struct {
+	suite.Suite
+}
+
+func (suite *TokenReaderSuite) encode(tag Tag, order binary.ByteOrder) *bytes.Buffer {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, order).WriteTag(tag))
+	return &buf
+}
+
+func (suite *TokenReaderSuite) chunk() Tag {
+	return NewCompoundTag("", []Tag{
+		NewCompoundTag("Level", []Tag{
+			NewIntTag("xPos", -3),
+			NewListTag("Sections", []Tag{
+				NewCompoundTag("", []Tag{
+					NewByteTag("Y", 0),
+					NewLongArrayTag("BlockStates", []int64{1, 2, 3}),
+				}),
+			}, IDTagCompound),
+			NewStringTag("Status", "full"),
+		}),
+		NewListTag("Empty", nil, IDTagEnd),
+		NewDoubleTag("d", 1.5),
+	})
+}
+
+func (suite *TokenReaderSuite) TestToken() {
+	r := NewTokenReader(suite.encode(suite.chunk(), binary.BigEndian), binary.BigEndian)
+	for _, expected := range []Token{
+		{Kind: TokenBeginCompound, ID: IDTagCompound},
+		{Kind: TokenBeginCompound, ID: IDTagCompound, Name: "Level"},
+		{Kind: TokenValue, ID: IDTagInt, Name: "xPos", Value: int32(-3)},
+		{Kind: TokenBeginList, ID: IDTagList, Name: "Sections", ElementType: IDTagCompound, Len: 1},
+		{Kind: TokenBeginCompound, ID: IDTagCompound},
+		{Kind: TokenValue, ID: IDTagByte, Name: "Y", Value: int8(0)},
+		{Kind: TokenValue, ID: IDTagLongArray, Name: "BlockStates", Value: []int64{1, 2, 3}},
+		{Kind: TokenEnd},
+		{Kind: TokenEnd},
+		{Kind: TokenValue, ID: IDTagString, Name: "Status", Value: "full"},
+		{Kind: TokenEnd},
+		{Kind: TokenBeginList, ID: IDTagList, Name: "Empty", ElementType: IDTagEnd},
+		{Kind: TokenEnd},
+		{Kind: TokenValue, ID: IDTagDouble, Name: "d", Value: 1.5},
+		{Kind: TokenEnd},
+	} {
+		tok, err := r.Token()
+		suite.NoError(err)
+		suite.Equal(expected, tok)
+	}
+	_, err := r.Token()
+	suite.Equal(io.EOF, err)
+}
+
+func (suite *TokenReaderSuite) TestSkip() {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian, NetworkLittleEndian} {
+		r := NewTokenReader(suite.encode(suite.chunk(), order), order)
+
+		tok, err := r.Token() // root
+		suite.NoError(err)
+		suite.Equal(TokenBeginCompound, tok.Kind)
+
+		tok, err = r.Token() // Level
+		suite.NoError(err)
+		suite.Equal("Level", tok.Name)
+		suite.NoError(r.Skip())
+
+		tok, err = r.Token()
+		suite.NoError(err)
+		suite.Equal("Empty", tok.Name)
+		suite.NoError(r.Skip())
+
+		tok, err = r.Token()
+		suite.NoError(err)
+		suite.Equal(1.5, tok.Value)
+		suite.NoError(r.Skip()) // rest of the root
+
+		_, err = r.Token()
+		suite.Equal(io.EOF, err, order)
+	}
+}
+
+func (suite *TokenReaderSuite) TestSkip_PartialList() {
+	list := NewListTag("", []Tag{NewStringTag("", "a"), NewStringTag("", "b"), NewStringTag("", "c")}, IDTagString)
+	buf := suite.encode(list, binary.BigEndian)
+	buf.WriteByte(0x42) // data after the tag must remain unread
+
+	r := NewTokenReader(buf, binary.BigEndian)
+	_, err := r.Token()
+	suite.NoError(err)
+	tok, err := r.Token()
+	suite.NoError(err)
+	suite.Equal("a", tok.Value)
+	suite.NoError(r.Skip())
+	suite.Equal([]byte{0x42}, buf.Bytes())
+
+	suite.Error(r.Skip())
+}
+
+func (suite *TokenReaderSuite) TestNamelessRoot() {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian, NamelessRoot()).WriteTag(NewIntTag("ignored", 5)))
+	tok, err := NewTokenReader(&buf, binary.BigEndian, NamelessRoot()).Token()
+	suite.NoError(err)
+	suite.Equal(Token{Kind: TokenValue, ID: IDTagInt, Value: int32(5)}, tok)
+}
+
+func (suite *TokenReaderSuite) TestTruncated() {
+	data := suite.encode(suite.chunk(), binary.BigEndian).Bytes()
+	for i := 1; i < len(data); i++ {
+		r := NewTokenReader(bytes.NewReader(data[:i]), binary.BigEndian)
+		var err error
+		for err == nil {
+			_, err = r.Token()
+		}
+		suite.NotEqual(io.EOF, err, "truncated at %d", i)
+
+		r = NewTokenReader(bytes.NewReader(data[:i]), binary.BigEndian)
+		if _, err := r.Token(); err == nil {
+			suite.Error(r.Skip(), "truncated at %d", i)
+		}
+	}
+}
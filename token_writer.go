@@ -0,0 +1,290 @@
+package nbt
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TokenWriter writes NBT data token by token, without building the tags in memory
+// first. Obtain one with NewTokenWriter.
+//
+// Every written tag is either a root tag, a tag in the compound that was begun last,
+// or an element in the list that was begun last. Elements of a list must have the
+// element type of the list, and their name is ignored. Every BeginCompound and BeginList
+// must be followed by a call to End, after all tags of the compound or all elements of
+// the list have been written.
+type TokenWriter struct {
+	w        io.Writer
+	bo       binary.ByteOrder
+	nameless bool
+	raw      bool
+
+	compression Compression
+	compressor  io.WriteCloser
+	target      io.Writer
+
+	stack []tokenFrame
+	err   error
+}
+
+// NewTokenWriter creates a new TokenWriter that writes to the given writer with the
+// given byte order. The Canonical option has no effect on a TokenWriter, since tags
+// are written in the order in which they are passed. If compression is enabled, all
+// tags are written into a single compressed stream, which is completed by Close.
+func NewTokenWriter(target io.Writer, byteOrder binary.ByteOrder, opts ...EncoderOption) *TokenWriter {
+	e := &encoder{
+		w:  target,
+		bo: byteOrder,
+	}
+	for _, opt := range opts {
+		opt.applyEncoder(e)
+	}
+	return &TokenWriter{
+		w:           e.w,
+		bo:          e.bo,
+		nameless:    e.nameless,
+		raw:         e.raw,
+		compression: e.compression,
+	}
+}
+
+// BeginCompound begins a compound with the given name. Tags written after this are put
+// into the compound, until End is called.
+func (w *TokenWriter) BeginCompound(name string) error {
+	if err := w.begin(IDTagCompound, name); err != nil {
+		return err
+	}
+	w.stack = append(w.stack, tokenFrame{})
+	return nil
+}
+
+// BeginList begins a list with the given name, element type and length. Exactly length
+// elements of the given type must be written after this, before End is called.
+func (w *TokenWriter) BeginList(name string, elementType ID, length int) error {
+	if elementType >= NumIDTags {
+		return fmt.Errorf("unknown list type %s", elementType)
+	}
+	if length < 0 {
+		return fmt.Errorf("negative list length %d", length)
+	}
+	if elementType == IDTagEnd && length > 0 {
+		return fmt.Errorf("list of %s can't have elements", elementType)
+	}
+	if err := w.begin(IDTagList, name); err != nil {
+		return err
+	}
+	w.stack = append(w.stack, tokenFrame{
+		list:        true,
+		elementType: elementType,
+		remaining:   uint32(length),
+	})
+	return w.write(func(wr io.Writer) error {
+		if err := writeByte(wr, w.bo, byte(elementType)); err != nil {
+			return err
+		}
+		return writeLength(wr, w.bo, length)
+	})
+}
+
+// End ends the compound or list that was begun last.
+func (w *TokenWriter) End() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.stack) == 0 {
+		return errors.New("no compound or list to end")
+	}
+
+	top := w.stack[len(w.stack)-1]
+	if top.list {
+		if top.remaining > 0 {
+			return fmt.Errorf("list of %s is missing %d elements", top.elementType, top.remaining)
+		}
+		w.stack = w.stack[:len(w.stack)-1]
+		return nil
+	}
+	w.stack = w.stack[:len(w.stack)-1]
+	return w.write(func(wr io.Writer) error {
+		return writeByte(wr, w.bo, byte(IDTagEnd))
+	})
+}
+
+// WriteByteTag writes a byte tag with the given name and value. It is not called
+// WriteByte, since that name is reserved for the signature of io.ByteWriter.
+func (w *TokenWriter) WriteByteTag(name string, v int8) error {
+	return w.writeValue(IDTagByte, name, func(wr io.Writer) error {
+		return writeByte(wr, w.bo, byte(v))
+	})
+}
+
+// WriteShort writes a short tag with the given name and value.
+func (w *TokenWriter) WriteShort(name string, v int16) error {
+	return w.writeValue(IDTagShort, name, func(wr io.Writer) error {
+		return writeUint16(wr, w.bo, uint16(v))
+	})
+}
+
+// WriteInt writes an int tag with the given name and value.
+func (w *TokenWriter) WriteInt(name string, v int32) error {
+	return w.writeValue(IDTagInt, name, func(wr io.Writer) error {
+		return writeInt32(wr, w.bo, v)
+	})
+}
+
+// WriteLong writes a long tag with the given name and value.
+func (w *TokenWriter) WriteLong(name string, v int64) error {
+	return w.writeValue(IDTagLong, name, func(wr io.Writer) error {
+		return writeInt64(wr, w.bo, v)
+	})
+}
+
+// WriteFloat writes a float tag with the given name and value.
+func (w *TokenWriter) WriteFloat(name string, v float32) error {
+	return w.writeValue(IDTagFloat, name, func(wr io.Writer) error {
+		return writeFloat32(wr, w.bo, v)
+	})
+}
+
+// WriteDouble writes a double tag with the given name and value.
+func (w *TokenWriter) WriteDouble(name string, v float64) error {
+	return w.writeValue(IDTagDouble, name, func(wr io.Writer) error {
+		return writeFloat64(wr, w.bo, v)
+	})
+}
+
+// WriteString writes a string tag with the given name and value.
+func (w *TokenWriter) WriteString(name string, v string) error {
+	return w.writeValue(IDTagString, name, func(wr io.Writer) error {
+		return writeString(wr, w.bo, v)
+	})
+}
+
+// WriteByteArray writes a byte array tag with the given name and value.
+func (w *TokenWriter) WriteByteArray(name string, v []int8) error {
+	return w.WriteTag(NewByteArrayTag(name, v))
+}
+
+// WriteIntArray writes an int array tag with the given name and value.
+func (w *TokenWriter) WriteIntArray(name string, v []int32) error {
+	return w.WriteTag(NewIntArrayTag(name, v))
+}
+
+// WriteLongArray writes a long array tag with the given name and value.
+func (w *TokenWriter) WriteLongArray(name string, v []int64) error {
+	return w.WriteTag(NewLongArrayTag(name, v))
+}
+
+// WriteTag writes the given tag, including all nested tags, at once.
+func (w *TokenWriter) WriteTag(tag Tag) error {
+	if tag != nil && tag.ID() == IDTagEnd {
+		return fmt.Errorf("can't write %s, use End instead", tag.ID())
+	}
+	if err := validate(tag, w.bo, w.raw); err != nil {
+		return err
+	}
+	return w.writeValue(tag.ID(), tag.Name(), func(wr io.Writer) error {
+		return tag.WriteTo(wr, w.bo)
+	})
+}
+
+// Close checks that all compounds and lists have been ended, and completes the
+// compressed stream, if compression is enabled. It does not close the underlying writer.
+func (w *TokenWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.stack) > 0 {
+		return fmt.Errorf("%d compounds or lists not ended", len(w.stack))
+	}
+	if w.compressor != nil {
+		w.err = w.compressor.Close()
+		if w.err != nil {
+			return fmt.Errorf("%s: %w", w.compression, w.err)
+		}
+	}
+	w.err = errors.New("token writer closed")
+	return nil
+}
+
+func (w *TokenWriter) writeValue(id ID, name string, payload func(io.Writer) error) error {
+	if err := w.begin(id, name); err != nil {
+		return err
+	}
+	return w.write(payload)
+}
+
+// begin checks that a tag with the given ID can be written at the current position,
+// and writes the ID and the name of the tag, if it's not a list element.
+func (w *TokenWriter) begin(id ID, name string) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if len(w.stack) > 0 {
+		top := &w.stack[len(w.stack)-1]
+		if top.list {
+			if id != top.elementType {
+				return fmt.Errorf("can't write %s into list of %s", id, top.elementType)
+			}
+			if top.remaining == 0 {
+				return fmt.Errorf("list of %s has no more elements left", top.elementType)
+			}
+			top.remaining--
+			return nil
+		}
+	}
+
+	nameless := len(w.stack) == 0 && w.nameless
+	return w.write(func(wr io.Writer) error {
+		if err := writeByte(wr, w.bo, byte(id)); err != nil {
+			return fmt.Errorf("write ID: %w", err)
+		}
+		if nameless {
+			return nil
+		}
+		if err := writeString(wr, w.bo, name); err != nil {
+			return fmt.Errorf("write tag name: %w", err)
+		}
+		return nil
+	})
+}
+
+// write calls the given function with the target writer. Once writing failed, the
+// written data is incomplete, so the error is returned by all subsequent calls.
+func (w *TokenWriter) write(fn func(io.Writer) error) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.target == nil {
+		w.target = w.w
+		switch w.compression {
+		case CompressionNone:
+		case CompressionGzip:
+			w.compressor = gzip.NewWriter(w.w)
+		case CompressionZlib:
+			w.compressor = zlib.NewWriter(w.w)
+		default:
+			w.err = fmt.Errorf("unsupported compression %s", w.compression)
+			return w.err
+		}
+		if w.compressor != nil {
+			w.target = w.compressor
+		}
+		// tags passed to WriteTag have already been validated, so the encoders
+		// of their nested tags must not validate them again
+		w.target = &encodeWriter{
+			Writer: w.target,
+			raw:    w.raw,
+		}
+	}
+
+	if err := fn(w.target); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
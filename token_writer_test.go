@@ -0,0 +1,104 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestTokenWriterSuite(t *testing.T) {
+	suite.Run(t, new(TokenWriterSuite))
+}
+
+type TokenWriterSuite struct {
+	suite.Suite
+}
+
+func (suite *TokenWriterSuite) TestWrite() {
+	expected := NewCompoundTag("root", []Tag{
+		NewByteTag("b", 1),
+		NewShortTag("s", 2),
+		NewIntTag("i", 3),
+		NewLongTag("l", 4),
+		NewFloatTag("f", 5.5),
+		NewDoubleTag("d", 6.5),
+		NewStringTag("str", "seven"),
+		NewByteArrayTag("ba", []int8{8}),
+		NewIntArrayTag("ia", []int32{9}),
+		NewLongArrayTag("la", []int64{10}),
+		NewListTag("list", []Tag{
+			NewCompoundTag("", []Tag{NewIntTag("x", 11)}),
+			NewCompoundTag("", nil),
+		}, IDTagCompound),
+		NewListTag("empty", nil, IDTagEnd),
+		NewCompoundTag("tag", []Tag{NewStringTag("a", "b")}),
+	})
+
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian, NetworkLittleEndian} {
+		var buf bytes.Buffer
+		w := NewTokenWriter(&buf, order)
+		suite.NoError(w.BeginCompound("root"))
+		suite.NoError(w.WriteByteTag("b", 1))
+		suite.NoError(w.WriteShort("s", 2))
+		suite.NoError(w.WriteInt("i", 3))
+		suite.NoError(w.WriteLong("l", 4))
+		suite.NoError(w.WriteFloat("f", 5.5))
+		suite.NoError(w.WriteDouble("d", 6.5))
+		suite.NoError(w.WriteString("str", "seven"))
+		suite.NoError(w.WriteByteArray("ba", []int8{8}))
+		suite.NoError(w.WriteIntArray("ia", []int32{9}))
+		suite.NoError(w.WriteLongArray("la", []int64{10}))
+		suite.NoError(w.BeginList("list", IDTagCompound, 2))
+		suite.NoError(w.BeginCompound(""))
+		suite.NoError(w.WriteInt("x", 11))
+		suite.NoError(w.End())
+		suite.NoError(w.BeginCompound("ignored"))
+		suite.NoError(w.End())
+		suite.NoError(w.End())
+		suite.NoError(w.BeginList("empty", IDTagEnd, 0))
+		suite.NoError(w.End())
+		suite.NoError(w.WriteTag(NewCompoundTag("tag", []Tag{NewStringTag("a", "b")})))
+		suite.NoError(w.End())
+		suite.NoError(w.Close())
+
+		var want bytes.Buffer
+		suite.NoError(NewEncoder(&want, order).WriteTag(expected))
+		suite.Equal(want.Bytes(), buf.Bytes(), order)
+	}
+}
+
+func (suite *TokenWriterSuite) TestOptions() {
+	var buf bytes.Buffer
+	w := NewTokenWriter(&buf, binary.BigEndian, NamelessRoot(), WithCompression(CompressionGzip))
+	suite.NoError(w.BeginCompound("ignored"))
+	suite.NoError(w.WriteString("text", "hi"))
+	suite.NoError(w.End())
+	suite.NoError(w.Close())
+
+	dec, err := NewDecompressingDecoder(&buf, binary.BigEndian, NamelessRoot())
+	suite.NoError(err)
+	tag, err := dec.ReadTag()
+	suite.NoError(err)
+	suite.Equal(ToString(NewCompoundTag("", []Tag{NewStringTag("text", "hi")})), ToString(tag))
+}
+
+func (suite *TokenWriterSuite) TestMisuse() {
+	w := NewTokenWriter(&bytes.Buffer{}, binary.BigEndian)
+	suite.Error(w.End())
+	suite.Error(w.BeginList("", IDTagEnd, 1))
+	suite.Error(w.BeginList("", IDTagInt, -1))
+	suite.Error(w.WriteTag(NewEndTag()))
+
+	suite.NoError(w.BeginList("", IDTagInt, 2))
+	suite.Error(w.WriteLong("", 1))
+	suite.NoError(w.WriteInt("", 1))
+	suite.Error(w.End()) // one element missing
+	suite.Error(w.Close())
+	suite.NoError(w.WriteInt("", 2))
+	suite.Error(w.WriteInt("", 3)) // too many elements
+	suite.NoError(w.End())
+	suite.NoError(w.Close())
+	suite.Error(w.WriteInt("", 4)) // closed
+}
@@ -1,56 +1,231 @@
 package nbt
 
 import (
+	"encoding"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Unmarshaler is implemented by types that can unmarshal a tag into themselves.
+type Unmarshaler interface {
+	UnmarshalNBT(Tag) error
+}
+
+var (
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 )
 
 // UnmarshalReader unmarshals NBT data from the given reader into the given interface.
-func UnmarshalReader(rd io.Reader, order binary.ByteOrder, v interface{}) error {
-	dec := NewDecoder(rd, order)
+// The given options are passed on to the underlying Decoder.
+//
+// Compounds can be unmarshalled into structs, whose unexported fields are ignored, and
+// into maps with string keys. Values of integer tags can be unmarshalled into all integer
+// types that they fit into. For unsigned integers, the bits are reinterpreted, e.g. a
+// byte tag with value -1 becomes 255 in an uint8. Double tags are only unmarshalled into
+// a float32 if their value can be represented exactly. If the target is an empty
+// interface, it is set to the natural Go value of the tag. Byte, short, int and long tags
+// become int8, int16, int32 and int64, float and double tags become float32 and float64,
+// and string tags become strings. Arrays become []int8, []int32 and []int64, lists become
+// []interface{} and compounds become map[string]interface{}.
+//
+// If the target implements Unmarshaler, its UnmarshalNBT method is called with the tag.
+// If it implements encoding.TextUnmarshaler and the tag is a string tag, its UnmarshalText
+// method is called with the value of the tag.
+//
+// If a tag can't be unmarshalled into the Go value at its position, an
+// *UnmarshalTypeError is returned, unless the SkipTypeMismatches option is used.
+func UnmarshalReader(rd io.Reader, order binary.ByteOrder, v interface{}, opts ...DecoderOption) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("unmarshal target must be a non-nil pointer, but is %T", v)
+	}
+
+	dec := NewDecoder(rd, order, opts...)
 	tag, err := dec.ReadTag()
 	if err != nil {
 		return fmt.Errorf("read tag: %w", err)
 	}
-	return unmarshalInto(tag, reflect.ValueOf(v).Elem())
+
+	u := unmarshaller{
+		skip: dec.(*decoder).skipTypeMismatches,
+	}
+	if err := u.unmarshalInto(tag, target.Elem()); err != nil && err != errSkipped {
+		return err
+	}
+	if len(u.skipped) > 0 {
+		return u.skipped
+	}
+	return nil
+}
+
+// unmarshaller keeps track of the path of the tag that is currently unmarshalled,
+// and of the tags that were skipped.
+type unmarshaller struct {
+	skip    bool
+	path    []string
+	skipped UnmarshalTypeErrors
+}
+
+// errSkipped is returned by the methods of an unmarshaller, if a tag was skipped,
+// and tells the caller to leave the target unchanged.
+var errSkipped = errors.New("skipped")
+
+// mismatch returns an *UnmarshalTypeError for the given tag at the current path, or
+// records it and returns errSkipped, if mismatches are skipped.
+func (u *unmarshaller) mismatch(tag Tag, target reflect.Value) error {
+	err := &UnmarshalTypeError{
+		Path: u.pathString(),
+		ID:   tag.ID(),
+		Type: target.Type(),
+	}
+	if u.skip {
+		u.skipped = append(u.skipped, err)
+		return errSkipped
+	}
+	return err
+}
+
+func (u *unmarshaller) pathString() string {
+	return strings.Join(u.path, "")
+}
+
+// unmarshalFromUnmarshaler passes the given tag to the given target, if the target
+// implements Unmarshaler or encoding.TextUnmarshaler, and returns whether it did.
+func (u *unmarshaller) unmarshalFromUnmarshaler(tag Tag, target reflect.Value) (bool, error) {
+	if !target.CanAddr() || !target.Addr().CanInterface() {
+		return false, nil
+	}
+	if !implementsUnmarshaler(target.Type()) {
+		return false, nil
+	}
+
+	var err error
+	switch m := target.Addr().Interface().(type) {
+	case Unmarshaler:
+		err = m.UnmarshalNBT(tag)
+	case encoding.TextUnmarshaler:
+		str, ok := tag.(*String)
+		if !ok {
+			return false, nil
+		}
+		err = m.UnmarshalText([]byte(str.Value))
+	default:
+		return false, nil
+	}
+	if err != nil {
+		path := u.pathString()
+		if path == "" {
+			path = "root"
+		}
+		return true, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	return true, nil
+}
+
+func (u *unmarshaller) unmarshalChild(tag Tag, name string, target reflect.Value) error {
+	if len(u.path) > 0 {
+		name = "." + name
+	}
+	u.path = append(u.path, name)
+	err := u.unmarshalInto(tag, target)
+	u.path = u.path[:len(u.path)-1]
+	return err
+}
+
+func (u *unmarshaller) unmarshalElement(tag Tag, i int, target reflect.Value) error {
+	u.path = append(u.path, "["+strconv.Itoa(i)+"]")
+	err := u.unmarshalInto(tag, target)
+	u.path = u.path[:len(u.path)-1]
+	return err
 }
 
-func unmarshalInto(tag Tag, target reflect.Value) error {
+func (u *unmarshaller) unmarshalInto(tag Tag, target reflect.Value) error {
 	if tag == nil {
 		// nothing to do if the tag doesn't exist
 		return nil
 	}
+	if ok, err := u.unmarshalFromUnmarshaler(tag, target); ok {
+		return err
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		if !target.IsNil() {
+			return u.unmarshalInto(tag, target.Elem())
+		}
+		ptr := reflect.New(target.Type().Elem())
+		if err := u.unmarshalInto(tag, ptr.Elem()); err != nil {
+			return err
+		}
+		target.Set(ptr)
+		return nil
+	case reflect.Interface:
+		value := reflect.ValueOf(unmarshalValue(tag))
+		if !value.IsValid() || !value.Type().AssignableTo(target.Type()) {
+			return u.mismatch(tag, target)
+		}
+		target.Set(value)
+		return nil
+	}
+
+	switch tag.(type) {
+	case *ByteArray, *IntArray, *LongArray:
+		if target.Kind() == reflect.Slice && implementsUnmarshaler(target.Type().Elem()) {
+			// every element must be passed to the unmarshaler of the element type
+			list, err := convertTag(tag, structTagList)
+			if err != nil {
+				return err
+			}
+			return u.unmarshalInto(list, target)
+		}
+	}
+
+	if !fits(tag, target.Type()) {
+		return u.mismatch(tag, target)
+	}
 
 	switch tag.ID() {
 	case IDTagByte:
-		target.SetInt(int64(tag.(*Byte).Value))
+		v := tag.(*Byte).Value
+		if target.Kind() == reflect.Bool {
+			target.SetBool(v != 0)
+		} else {
+			setInt(target, int64(v), uint64(uint8(v)))
+		}
 	case IDTagByteArray:
 		source := tag.(*ByteArray).Value
 		newTarget := reflect.MakeSlice(target.Type(), len(source), len(source))
 		for i := 0; i < newTarget.Len(); i++ {
-			newTarget.Index(i).SetInt(int64(source[i]))
+			setInt(newTarget.Index(i), int64(source[i]), uint64(uint8(source[i])))
 		}
 		target.Set(newTarget)
 	case IDTagShort:
-		target.SetInt(int64(tag.(*Short).Value))
+		v := tag.(*Short).Value
+		setInt(target, int64(v), uint64(uint16(v)))
 	case IDTagInt:
-		target.SetInt(int64(tag.(*Int).Value))
+		v := tag.(*Int).Value
+		setInt(target, int64(v), uint64(uint32(v)))
 	case IDTagIntArray:
 		source := tag.(*IntArray).Value
 		newTarget := reflect.MakeSlice(target.Type(), len(source), len(source))
 		for i := 0; i < newTarget.Len(); i++ {
-			newTarget.Index(i).SetInt(int64(source[i]))
+			setInt(newTarget.Index(i), int64(source[i]), uint64(uint32(source[i])))
 		}
 		target.Set(newTarget)
 	case IDTagLong:
-		target.SetInt(tag.(*Long).Value)
+		v := tag.(*Long).Value
+		setInt(target, v, uint64(v))
 	case IDTagLongArray:
 		source := tag.(*LongArray).Value
 		newTarget := reflect.MakeSlice(target.Type(), len(source), len(source))
 		for i := 0; i < newTarget.Len(); i++ {
-			newTarget.Index(i).SetInt(source[i])
+			setInt(newTarget.Index(i), source[i], uint64(source[i]))
 		}
 		target.Set(newTarget)
 	case IDTagFloat:
@@ -60,6 +235,10 @@ func unmarshalInto(tag Tag, target reflect.Value) error {
 	case IDTagString:
 		target.SetString(tag.(*String).Value)
 	case IDTagCompound:
+		if target.Kind() == reflect.Map {
+			return u.unmarshalMap(tag.(*Compound), target)
+		}
+
 		values := tag.(*Compound).Value
 		targetType := target.Type()
 		for i := 0; i < targetType.NumField(); i++ {
@@ -69,16 +248,22 @@ func unmarshalInto(tag Tag, target reflect.Value) error {
 			tagValue := parseStructTag(typeField.Tag.Get(structTag))
 			if tagValue.ignore {
 				continue
+			} else if typeField.PkgPath != "" || !field.CanSet() {
+				// unexported fields can't be set
+				continue
 			} else if tagValue.name != "" {
 				actualName = tagValue.name
 			}
 
-			actualField := field
-			if field.Kind() == reflect.Ptr {
-				actualField.Set(reflect.New(field.Type().Elem()))
-				actualField = actualField.Elem()
+			child := values[actualName]
+			if child != nil && tagValue.typ != "" && !fits(child, field.Type()) {
+				// accept tags that can be converted into the type of the field without
+				// losing data
+				if converted, err := convertTag(child, tagValue.typ); err == nil {
+					child = converted
+				}
 			}
-			if err := unmarshalInto(values[actualName], actualField); err != nil {
+			if err := u.unmarshalChild(child, actualName, field); err != nil && err != errSkipped {
 				return err
 			}
 		}
@@ -86,13 +271,184 @@ func unmarshalInto(tag Tag, target reflect.Value) error {
 		source := tag.(*List).Value
 		newTarget := reflect.MakeSlice(target.Type(), len(source), len(source))
 		for i := 0; i < newTarget.Len(); i++ {
-			if err := unmarshalInto(source[i], newTarget.Index(i)); err != nil {
+			if err := u.unmarshalElement(source[i], i, newTarget.Index(i)); err != nil && err != errSkipped {
 				return err
 			}
 		}
 		target.Set(newTarget)
+	}
+	return nil
+}
+
+// unmarshalMap puts the tags of the given compound into the given map, which must
+// have string keys. Existing entries of the map are kept, unless they are overwritten.
+func (u *unmarshaller) unmarshalMap(tag *Compound, target reflect.Value) error {
+	targetType := target.Type()
+	if targetType.Key().Kind() != reflect.String {
+		return u.mismatch(tag, target)
+	}
+	if target.IsNil() {
+		target.Set(reflect.MakeMapWithSize(targetType, len(tag.Value)))
+	}
+	for _, child := range tag.Tags() {
+		elem := reflect.New(targetType.Elem()).Elem()
+		if err := u.unmarshalChild(child, child.Name(), elem); err == errSkipped {
+			continue
+		} else if err != nil {
+			return err
+		}
+		target.SetMapIndex(reflect.ValueOf(child.Name()).Convert(targetType.Key()), elem)
+	}
+	return nil
+}
+
+// implementsUnmarshaler returns whether pointers to values of the given type implement
+// Unmarshaler or encoding.TextUnmarshaler.
+func implementsUnmarshaler(typ reflect.Type) bool {
+	ptrType := reflect.PtrTo(typ)
+	return ptrType.Implements(unmarshalerType) || ptrType.Implements(textUnmarshalerType)
+}
+
+// fits returns whether the given tag can be unmarshalled into a Go value of the given
+// type without losing data. For compounds and lists, only the given type is checked,
+// but not the types of the fields or elements.
+func fits(tag Tag, typ reflect.Type) bool {
+	if reflect.PtrTo(typ).Implements(unmarshalerType) {
+		return true
+	}
+	if _, ok := tag.(*String); ok && reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return fits(tag, typ.Elem())
+	case reflect.Interface:
+		v := unmarshalValue(tag)
+		return v != nil && reflect.TypeOf(v).AssignableTo(typ)
+	}
+
+	switch tag := tag.(type) {
+	case *Byte:
+		return typ.Kind() == reflect.Bool || intFits(int64(tag.Value), uint64(uint8(tag.Value)), typ)
+	case *Short:
+		return intFits(int64(tag.Value), uint64(uint16(tag.Value)), typ)
+	case *Int:
+		return intFits(int64(tag.Value), uint64(uint32(tag.Value)), typ)
+	case *Long:
+		return intFits(tag.Value, uint64(tag.Value), typ)
+	case *Float:
+		return typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64
+	case *Double:
+		return typ.Kind() == reflect.Float64 || (typ.Kind() == reflect.Float32 && floatFits(tag.Value, structTagFloat))
+	case *String:
+		return typ.Kind() == reflect.String
+	case *ByteArray:
+		if typ.Kind() != reflect.Slice {
+			return false
+		}
+		for _, v := range tag.Value {
+			if !intFits(int64(v), uint64(uint8(v)), typ.Elem()) {
+				return false
+			}
+		}
+		return isInt(typ.Elem())
+	case *IntArray:
+		if typ.Kind() != reflect.Slice {
+			return false
+		}
+		for _, v := range tag.Value {
+			if !intFits(int64(v), uint64(uint32(v)), typ.Elem()) {
+				return false
+			}
+		}
+		return isInt(typ.Elem())
+	case *LongArray:
+		if typ.Kind() != reflect.Slice {
+			return false
+		}
+		for _, v := range tag.Value {
+			if !intFits(v, uint64(v), typ.Elem()) {
+				return false
+			}
+		}
+		return isInt(typ.Elem())
+	case *List:
+		return typ.Kind() == reflect.Slice
+	case *Compound:
+		return typ.Kind() == reflect.Struct || (typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String)
+	}
+	return false
+}
+
+func isInt(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// intFits returns whether the value of an integer tag fits into an integer of the given
+// type. For unsigned types, the unsigned value with the same bits as the tag is used.
+func intFits(signed int64, unsigned uint64, typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return typ.Bits() == 64 || (signed >= -1<<(typ.Bits()-1) && signed < 1<<(typ.Bits()-1))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return typ.Bits() == 64 || unsigned < 1<<typ.Bits()
+	}
+	return false
+}
+
+// setInt sets the given integer target to the given signed value, or to the given
+// unsigned value with the same bits, if the target is unsigned.
+func setInt(target reflect.Value, signed int64, unsigned uint64) {
+	switch target.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		target.SetUint(unsigned)
 	default:
-		return fmt.Errorf("unsupported type %s", tag.ID())
+		target.SetInt(signed)
+	}
+}
+
+// unmarshalValue returns the natural Go value of the given tag, which is used for
+// targets of type interface{}.
+func unmarshalValue(tag Tag) interface{} {
+	switch tag := tag.(type) {
+	case *Byte:
+		return tag.Value
+	case *Short:
+		return tag.Value
+	case *Int:
+		return tag.Value
+	case *Long:
+		return tag.Value
+	case *Float:
+		return tag.Value
+	case *Double:
+		return tag.Value
+	case *String:
+		return tag.Value
+	case *ByteArray:
+		return append([]int8{}, tag.Value...)
+	case *IntArray:
+		return append([]int32{}, tag.Value...)
+	case *LongArray:
+		return append([]int64{}, tag.Value...)
+	case *List:
+		values := make([]interface{}, len(tag.Value))
+		for i, elem := range tag.Value {
+			values[i] = unmarshalValue(elem)
+		}
+		return values
+	case *Compound:
+		values := make(map[string]interface{}, len(tag.Value))
+		for _, child := range tag.Tags() {
+			values[child.Name()] = unmarshalValue(child)
+		}
+		return values
 	}
 	return nil
 }
@@ -0,0 +1,49 @@
+package nbt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UnmarshalTypeError is returned by UnmarshalReader, if a tag can't be unmarshalled
+// into the Go value at its position, e.g. a string tag into an int32.
+type UnmarshalTypeError struct {
+	// Path is the path of the tag, e.g. Level.Sections[3].Y. The root tag has an
+	// empty path.
+	Path string
+	// ID is the type of the tag.
+	ID ID
+	// Type is the type of the Go value that the tag couldn't be unmarshalled into.
+	Type reflect.Type
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "root"
+	}
+	return fmt.Sprintf("unmarshal %s: can't unmarshal %s into Go value of type %s", path, e.ID, e.Type)
+}
+
+// UnmarshalTypeErrors is returned by UnmarshalReader if the SkipTypeMismatches option
+// is used, and at least one tag was skipped. It contains an error for every skipped tag.
+type UnmarshalTypeErrors []*UnmarshalTypeError
+
+func (e UnmarshalTypeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d tags skipped: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// SkipTypeMismatches is an option for UnmarshalReader. Tags that can't be unmarshalled
+// into the Go value at their position are skipped, and the Go value is left unchanged.
+// All other tags are still unmarshalled, and an UnmarshalTypeErrors that contains all
+// skipped tags is returned at the end. It has no effect on a Decoder.
+func SkipTypeMismatches() DecoderOption {
+	return decoderOptionFunc(func(d *decoder) {
+		d.skipTypeMismatches = true
+	})
+}
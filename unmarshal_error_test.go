@@ -0,0 +1,215 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestUnmarshalErrorSuite(t *testing.T) {
+	suite.Run(t, new(UnmarshalErrorSuite))
+}
+
+type UnmarshalErrorSuite struct {
+	suite.Suite
+}
+
+type unmarshalErrorSection struct {
+	Y           int8
+	BlockStates []int64
+}
+
+type unmarshalErrorChunk struct {
+	Level struct {
+		XPos     int32 `nbt:"xPos"`
+		Status   string
+		Sections []unmarshalErrorSection
+	}
+}
+
+// chunk returns an encoded chunk, where the Y of the third section and the
+// status are strings.
+func (suite *UnmarshalErrorSuite) chunk() []byte {
+	sections := make([]Tag, 4)
+	for i := range sections {
+		var y Tag = NewByteTag("Y", int8(i))
+		if i == 2 {
+			y = NewStringTag("Y", "2")
+		}
+		sections[i] = NewCompoundTag("", []Tag{
+			y,
+			NewLongArrayTag("BlockStates", []int64{1, 2, 3}),
+		})
+	}
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewCompoundTag("", []Tag{
+		NewCompoundTag("Level", []Tag{
+			NewIntTag("xPos", 1),
+			NewIntTag("Status", 5),
+			NewListTag("Sections", sections, IDTagCompound),
+		}),
+	})))
+	return buf.Bytes()
+}
+
+func (suite *UnmarshalErrorSuite) TestTypeError() {
+	var target unmarshalErrorChunk
+	err := UnmarshalReader(bytes.NewReader(suite.chunk()), binary.BigEndian, &target)
+
+	var typeErr *UnmarshalTypeError
+	suite.Require().True(errors.As(err, &typeErr), "%v", err)
+	suite.Equal("Level.Status", typeErr.Path)
+	suite.Equal(IDTagInt, typeErr.ID)
+	suite.Equal(reflect.TypeOf(""), typeErr.Type)
+	suite.EqualError(err, "unmarshal Level.Status: can't unmarshal TagInt into Go value of type string")
+}
+
+func (suite *UnmarshalErrorSuite) TestTypeErrorListElement() {
+	var target unmarshalErrorChunk
+	target.Level.Status = "full"
+
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewCompoundTag("", []Tag{
+		NewCompoundTag("Level", []Tag{
+			NewListTag("Sections", []Tag{
+				NewCompoundTag("", []Tag{NewByteTag("Y", 0)}),
+				NewCompoundTag("", []Tag{NewLongTag("BlockStates", 5)}),
+			}, IDTagCompound),
+		}),
+	})))
+	err := UnmarshalReader(&buf, binary.BigEndian, &target)
+
+	var typeErr *UnmarshalTypeError
+	suite.Require().True(errors.As(err, &typeErr), "%v", err)
+	suite.Equal("Level.Sections[1].BlockStates", typeErr.Path)
+	suite.Equal(IDTagLong, typeErr.ID)
+	suite.Equal(reflect.TypeOf([]int64{}), typeErr.Type)
+}
+
+func (suite *UnmarshalErrorSuite) TestTypeErrorRoot() {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewStringTag("", "text")))
+
+	var target int32
+	err := UnmarshalReader(&buf, binary.BigEndian, &target)
+	suite.EqualError(err, "unmarshal root: can't unmarshal TagString into Go value of type int32")
+}
+
+func (suite *UnmarshalErrorSuite) TestMismatches() {
+	tests := []struct {
+		name   string
+		tag    Tag
+		target interface{}
+	}{
+		{"string into int", NewStringTag("", "1"), new(int)},
+		{"int into string", NewIntTag("", 1), new(string)},
+		{"short into bool", NewShortTag("", 1), new(bool)},
+		{"double into int", NewDoubleTag("", 1), new(int64)},
+		{"long into float", NewLongTag("", 1), new(float64)},
+		{"compound into int", NewCompoundTag("", nil), new(int)},
+		{"compound into slice", NewCompoundTag("", nil), new([]int)},
+		{"compound into int map", NewCompoundTag("", nil), new(map[int]string)},
+		{"list into struct", NewListTag("", nil, IDTagEnd), new(struct{})},
+		{"list into map", NewListTag("", nil, IDTagEnd), new(map[string]string)},
+		{"byte array into string slice", NewByteArrayTag("", nil), new([]string)},
+		{"int array into int", NewIntArrayTag("", nil), new(int32)},
+		{"long array into float slice", NewLongArrayTag("", nil), new([]float64)},
+		{"int into stringer", NewIntTag("", 1), new(interface{ String() string })},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			var buf bytes.Buffer
+			suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(tt.tag))
+
+			var typeErr *UnmarshalTypeError
+			err := UnmarshalReader(&buf, binary.BigEndian, tt.target)
+			suite.True(errors.As(err, &typeErr), "%v", err)
+		})
+	}
+}
+
+func (suite *UnmarshalErrorSuite) TestInvalidTarget() {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewIntTag("", 1)))
+
+	var target int32
+	suite.Error(UnmarshalReader(bytes.NewReader(buf.Bytes()), binary.BigEndian, target))
+	suite.Error(UnmarshalReader(bytes.NewReader(buf.Bytes()), binary.BigEndian, (*int32)(nil)))
+}
+
+func (suite *UnmarshalErrorSuite) TestSkipTypeMismatches() {
+	var target unmarshalErrorChunk
+	target.Level.Status = "unchanged"
+	err := UnmarshalReader(bytes.NewReader(suite.chunk()), binary.BigEndian, &target, SkipTypeMismatches())
+
+	var typeErrs UnmarshalTypeErrors
+	suite.Require().True(errors.As(err, &typeErrs), "%v", err)
+	suite.Require().Len(typeErrs, 2)
+	suite.Equal("Level.Status", typeErrs[0].Path)
+	suite.Equal("Level.Sections[2].Y", typeErrs[1].Path)
+	suite.Equal(IDTagString, typeErrs[1].ID)
+	suite.Equal(reflect.TypeOf(int8(0)), typeErrs[1].Type)
+	suite.EqualError(err, "2 tags skipped: "+
+		"unmarshal Level.Status: can't unmarshal TagInt into Go value of type string; "+
+		"unmarshal Level.Sections[2].Y: can't unmarshal TagString into Go value of type int8")
+
+	// all other tags must have been unmarshalled
+	suite.EqualValues(1, target.Level.XPos)
+	suite.Equal("unchanged", target.Level.Status)
+	suite.Equal([]unmarshalErrorSection{
+		{Y: 0, BlockStates: []int64{1, 2, 3}},
+		{Y: 1, BlockStates: []int64{1, 2, 3}},
+		{Y: 0, BlockStates: []int64{1, 2, 3}},
+		{Y: 3, BlockStates: []int64{1, 2, 3}},
+	}, target.Level.Sections)
+}
+
+func (suite *UnmarshalErrorSuite) TestSkipTypeMismatchesMap() {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewCompoundTag("", []Tag{
+		NewIntTag("a", 1),
+		NewStringTag("b", "2"),
+		NewCompoundTag("c", nil),
+	})))
+
+	var target map[string]*int32
+	err := UnmarshalReader(&buf, binary.BigEndian, &target, SkipTypeMismatches())
+	var typeErrs UnmarshalTypeErrors
+	suite.Require().True(errors.As(err, &typeErrs), "%v", err)
+	suite.Len(typeErrs, 2)
+
+	one := int32(1)
+	suite.Equal(map[string]*int32{"a": &one}, target)
+}
+
+func (suite *UnmarshalErrorSuite) TestSkipTypeMismatchesNone() {
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewIntTag("", 1)))
+
+	var target int32
+	suite.NoError(UnmarshalReader(&buf, binary.BigEndian, &target, SkipTypeMismatches()))
+	suite.EqualValues(1, target)
+}
+
+func (suite *UnmarshalErrorSuite) TestUnexportedField() {
+	type t struct {
+		X int32
+		x int32
+		y string `nbt:"Y"`
+	}
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewCompoundTag("", []Tag{
+		NewIntTag("X", 1),
+		NewIntTag("x", 2),
+		NewStringTag("Y", "3"),
+	})))
+
+	var target t
+	suite.NoError(UnmarshalReader(&buf, binary.BigEndian, &target))
+	suite.Equal(t{X: 1}, target)
+}
@@ -3,6 +3,7 @@ package nbt
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -244,3 +245,323 @@ func (suite *UnmarshalSuite) TestUnmarshalReader_StructTag_Omitempty() {
 		Z: "zVal", // omitempty does't have an effect during unmarshalling
 	}, target)
 }
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_Unsigned() {
+	type t struct {
+		B  uint8
+		S  uint16
+		I  uint32
+		L  uint64
+		BA []byte
+		IA []uint32
+		LA []uint64
+	}
+	var target t
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewByteTag("B", -1),
+		NewShortTag("S", -2),
+		NewIntTag("I", -3),
+		NewLongTag("L", -4),
+		NewByteArrayTag("BA", []int8{1, -1}),
+		NewIntArrayTag("IA", []int32{1, -1}),
+		NewLongArrayTag("LA", []int64{1, -1}),
+	}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal(t{
+		B:  0xff,
+		S:  0xfffe,
+		I:  0xfffffffd,
+		L:  0xfffffffffffffffc,
+		BA: []byte{1, 0xff},
+		IA: []uint32{1, 0xffffffff},
+		LA: []uint64{1, 0xffffffffffffffff},
+	}, target)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_Map() {
+	var target map[string]int32
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewIntTag("a", 1),
+		NewIntTag("b", 2),
+	}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal(map[string]int32{"a": 1, "b": 2}, target)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_MapExisting() {
+	target := map[string]string{"a": "old", "keep": "kept"}
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewStringTag("a", "new"),
+	}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal(map[string]string{"a": "new", "keep": "kept"}, target)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_MapOfStructs() {
+	type item struct {
+		Count int8
+		ID    string `nbt:"id"`
+	}
+	type t struct {
+		Items map[string]*item
+	}
+	var target t
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewCompoundTag("Items", []Tag{
+			NewCompoundTag("hand", []Tag{
+				NewByteTag("Count", 1),
+				NewStringTag("id", "minecraft:stone"),
+			}),
+		}),
+	}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal(t{
+		Items: map[string]*item{
+			"hand": {Count: 1, ID: "minecraft:stone"},
+		},
+	}, target)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_Interface() {
+	var target interface{}
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewByteTag("b", 1),
+		NewShortTag("s", 2),
+		NewIntTag("i", 3),
+		NewLongTag("l", 4),
+		NewFloatTag("f", 1.5),
+		NewDoubleTag("d", 2.5),
+		NewStringTag("str", "text"),
+		NewByteArrayTag("ba", []int8{1}),
+		NewIntArrayTag("ia", []int32{2}),
+		NewLongArrayTag("la", []int64{3}),
+		NewListTag("list", []Tag{NewStringTag("", "a")}, IDTagString),
+		NewListTag("empty", nil, IDTagEnd),
+		NewCompoundTag("c", []Tag{NewIntTag("x", 5)}),
+	}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal(map[string]interface{}{
+		"b":     int8(1),
+		"s":     int16(2),
+		"i":     int32(3),
+		"l":     int64(4),
+		"f":     float32(1.5),
+		"d":     2.5,
+		"str":   "text",
+		"ba":    []int8{1},
+		"ia":    []int32{2},
+		"la":    []int64{3},
+		"list":  []interface{}{"a"},
+		"empty": []interface{}{},
+		"c":     map[string]interface{}{"x": int32(5)},
+	}, target)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_InterfaceField() {
+	type t struct {
+		Data   map[string]interface{}
+		Values []interface{}
+	}
+	var target t
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewCompoundTag("Data", []Tag{NewStringTag("id", "minecraft:pig")}),
+		NewListTag("Values", []Tag{NewShortTag("", 1), NewShortTag("", 2)}, IDTagShort),
+	}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal(t{
+		Data:   map[string]interface{}{"id": "minecraft:pig"},
+		Values: []interface{}{int16(1), int16(2)},
+	}, target)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_Unmarshaler() {
+	type entity struct {
+		UUID   testUUID
+		Owner  *testUUID
+		Owners []testUUID
+	}
+	var target entity
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewIntArrayTag("UUID", []int32{1, 2, 3, -1}),
+		NewIntArrayTag("Owner", []int32{4, 5, 6, 7}),
+		NewListTag("Owners", []Tag{
+			NewIntArrayTag("", []int32{0, 0, 0, 1}),
+		}, IDTagIntArray),
+	}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal(entity{
+		UUID:   testUUID{0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0xff, 0xff, 0xff, 0xff},
+		Owner:  &testUUID{0, 0, 0, 4, 0, 0, 0, 5, 0, 0, 0, 6, 0, 0, 0, 7},
+		Owners: []testUUID{{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+	}, target)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_UnmarshalerError() {
+	type entity struct {
+		UUID testUUID
+	}
+	var target entity
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewStringTag("UUID", "not a uuid"),
+	}), binary.BigEndian)
+	err := UnmarshalReader(suite.buf, binary.BigEndian, &target)
+	suite.EqualError(err, "unmarshal UUID: UUID must be an int array with 4 elements")
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_TextUnmarshaler() {
+	type item struct {
+		ID testResourceLocation `nbt:"id"`
+	}
+	var target map[string]item
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewCompoundTag("hand", []Tag{
+			NewStringTag("id", "minecraft:stone"),
+		}),
+	}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal(map[string]item{
+		"hand": {ID: testResourceLocation{"minecraft", "stone"}},
+	}, target)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_TextUnmarshalerError() {
+	var target testResourceLocation
+	suite.writeTag(NewStringTag("", "stone"), binary.BigEndian)
+	suite.EqualError(UnmarshalReader(suite.buf, binary.BigEndian, &target), `unmarshal root: invalid resource location "stone"`)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_StructTag_Type() {
+	type t struct {
+		Pos    []int32 `nbt:"Pos,list"`
+		Count  int     `nbt:",byte"`
+		Flags  []byte  `nbt:",bytearray"`
+		Active bool    `nbt:",bool"`
+		Health float32 `nbt:",float"`
+		IDs    []int64 `nbt:",longarray"`
+	}
+	tests := []struct {
+		name string
+		tag  Tag
+	}{
+		{
+			"exact",
+			NewCompoundTag("", []Tag{
+				NewListTag("Pos", []Tag{NewIntTag("", 1), NewIntTag("", 2)}, IDTagInt),
+				NewByteTag("Count", 3),
+				NewByteArrayTag("Flags", []int8{1, -1}),
+				NewByteTag("Active", 1),
+				NewFloatTag("Health", 2.5),
+				NewLongArrayTag("IDs", []int64{4}),
+			}),
+		},
+		{
+			"compatible",
+			NewCompoundTag("", []Tag{
+				NewIntArrayTag("Pos", []int32{1, 2}),
+				NewIntTag("Count", 3),
+				NewListTag("Flags", []Tag{NewByteTag("", 1), NewByteTag("", -1)}, IDTagByte),
+				NewIntTag("Active", 7),
+				NewDoubleTag("Health", 2.5),
+				NewListTag("IDs", []Tag{NewLongTag("", 4)}, IDTagLong),
+			}),
+		},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.buf.Reset()
+			var target t
+			suite.writeTag(tt.tag, binary.BigEndian)
+			suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+			suite.Equal(t{
+				Pos:    []int32{1, 2},
+				Count:  3,
+				Flags:  []byte{1, 0xff},
+				Active: true,
+				Health: 2.5,
+				IDs:    []int64{4},
+			}, target)
+		})
+	}
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_StructTag_TypeMismatch() {
+	type t struct {
+		Count int `nbt:",byte"`
+	}
+	var target t
+	suite.writeTag(NewCompoundTag("", []Tag{
+		NewStringTag("Count", "3"),
+	}), binary.BigEndian)
+	var typeErr *UnmarshalTypeError
+	suite.True(errors.As(UnmarshalReader(suite.buf, binary.BigEndian, &target), &typeErr))
+	suite.Equal(IDTagString, typeErr.ID)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_UnmarshalerArrayElements() {
+	var target []testGameMode
+	suite.writeTag(NewIntArrayTag("", []int32{1, 0}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.Equal([]testGameMode{1, 0}, target)
+
+	suite.writeTag(NewIntArrayTag("", []int32{0, 5}), binary.BigEndian)
+	suite.EqualError(UnmarshalReader(suite.buf, binary.BigEndian, &target), "unmarshal [1]: invalid game mode TagInt(''): 5")
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_StructTag_TypeLossless() {
+	type t struct {
+		Count int32 `nbt:"Count,byte"`
+	}
+	var target t
+	suite.writeTag(NewCompoundTag("", []Tag{NewIntTag("Count", 300)}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.EqualValues(300, target.Count)
+
+	type small struct {
+		Count int8 `nbt:"Count,byte"`
+	}
+	var smallTarget small
+	suite.writeTag(NewCompoundTag("", []Tag{NewIntTag("Count", 300)}), binary.BigEndian)
+	var typeErr *UnmarshalTypeError
+	suite.Require().True(errors.As(UnmarshalReader(suite.buf, binary.BigEndian, &smallTarget), &typeErr))
+	suite.Equal("Count", typeErr.Path)
+	suite.Equal(IDTagInt, typeErr.ID)
+	suite.Zero(smallTarget.Count)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_StructTag_TypeLosslessFloat() {
+	type t struct {
+		Speed float32 `nbt:"Speed,float"`
+	}
+	var target t
+	suite.writeTag(NewCompoundTag("", []Tag{NewDoubleTag("Speed", 0.5)}), binary.BigEndian)
+	suite.NoError(UnmarshalReader(suite.buf, binary.BigEndian, &target))
+	suite.EqualValues(0.5, target.Speed)
+
+	suite.writeTag(NewCompoundTag("", []Tag{NewDoubleTag("Speed", 0.1)}), binary.BigEndian)
+	var typeErr *UnmarshalTypeError
+	suite.Require().True(errors.As(UnmarshalReader(suite.buf, binary.BigEndian, &target), &typeErr))
+	suite.Equal(IDTagDouble, typeErr.ID)
+}
+
+func (suite *UnmarshalSuite) TestUnmarshalReader_Overflow() {
+	tests := []struct {
+		name   string
+		tag    Tag
+		target interface{}
+	}{
+		{"int into int8", NewIntTag("", 300), new(int8)},
+		{"long into int32", NewLongTag("", 1<<40), new(int32)},
+		{"negative int into uint8", NewIntTag("", -1), new(uint8)},
+		{"int array into int8 slice", NewIntArrayTag("", []int32{1, 300}), new([]int8)},
+		{"long array into uint16 slice", NewLongArrayTag("", []int64{1 << 20}), new([]uint16)},
+		{"double into float32", NewDoubleTag("", 0.1), new(float32)},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.buf.Reset()
+			suite.writeTag(tt.tag, binary.BigEndian)
+			var typeErr *UnmarshalTypeError
+			suite.True(errors.As(UnmarshalReader(suite.buf, binary.BigEndian, tt.target), &typeErr))
+		})
+	}
+}
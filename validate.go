@@ -0,0 +1,152 @@
+package nbt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Errors that are wrapped in an EncodeError, if a tag can't be encoded. Check for them
+// with errors.Is.
+var (
+	// ErrNilTag is returned if a tag, a compound entry or a list element is nil.
+	ErrNilTag = errors.New("nil tag")
+	// ErrStringTooLong is returned if a string or a tag name is too long for the
+	// length prefix of the byte order.
+	ErrStringTooLong = errors.New("string too long")
+	// ErrListType is returned if the type of a list is unknown, or if a list of
+	// end tags has elements. This is also returned by a Decoder.
+	ErrListType = errors.New("invalid list type")
+	// ErrListElementType is returned if an element of a list doesn't have the type
+	// of the list.
+	ErrListElementType = errors.New("list element type mismatch")
+	// ErrUnexpectedEnd is returned if an end tag is used as entry of a compound.
+	ErrUnexpectedEnd = errors.New("unexpected end tag")
+)
+
+// EncodeError is returned by an Encoder, if the given tag or one of its nested tags
+// can't be encoded. In that case, nothing is written.
+type EncodeError struct {
+	// Path is the path of the invalid tag, e.g. Level.Sections[3].Palette. The root
+	// tag has an empty path.
+	Path string
+	// Err is the reason why the tag can't be encoded.
+	Err error
+}
+
+func (e *EncodeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("encode root: %v", e.Err)
+	}
+	return fmt.Sprintf("encode %s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the reason why the tag can't be encoded.
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// validate checks that the given tag can be encoded with the given byte order,
+// and returns an *EncodeError if not.
+func validate(tag Tag, order binary.ByteOrder, raw bool) error {
+	v := validator{
+		maxLength: maxStringLength(order),
+		modified:  !raw && isModifiedUTF8(order),
+	}
+	if tag != nil {
+		if err := v.checkString(tag.Name()); err != nil {
+			return &EncodeError{Err: fmt.Errorf("name: %w", err)}
+		}
+	}
+	return v.validate(tag, "")
+}
+
+type validator struct {
+	maxLength int
+	modified  bool
+}
+
+func (v validator) validate(tag Tag, path string) error {
+	if tag == nil {
+		return &EncodeError{Path: path, Err: ErrNilTag}
+	}
+
+	switch tag := tag.(type) {
+	case *String:
+		if err := v.checkString(tag.Value); err != nil {
+			return &EncodeError{Path: path, Err: err}
+		}
+	case *List:
+		if tag.ListType >= NumIDTags || (tag.ListType == IDTagEnd && len(tag.Value) > 0) {
+			return &EncodeError{Path: path, Err: fmt.Errorf("%w %s", ErrListType, tag.ListType)}
+		}
+		for i, elem := range tag.Value {
+			elemPath := path + "[" + strconv.Itoa(i) + "]"
+			if elem != nil && elem.ID() != tag.ListType {
+				return &EncodeError{Path: elemPath, Err: fmt.Errorf("%w: %s in list of %s", ErrListElementType, elem.ID(), tag.ListType)}
+			}
+			if err := v.validate(elem, elemPath); err != nil {
+				return err
+			}
+		}
+	case *Compound:
+		for _, k := range tag.Keys() {
+			child := tag.Value[k]
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			if child == nil {
+				return &EncodeError{Path: childPath, Err: ErrNilTag}
+			}
+			if child.ID() == IDTagEnd {
+				return &EncodeError{Path: childPath, Err: ErrUnexpectedEnd}
+			}
+			if err := v.checkString(child.Name()); err != nil {
+				return &EncodeError{Path: childPath, Err: fmt.Errorf("name: %w", err)}
+			}
+			if err := v.validate(child, childPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v validator) checkString(s string) error {
+	length := len(s)
+	if v.modified {
+		length = modifiedUTF8Length(s)
+	}
+	if length > v.maxLength {
+		return fmt.Errorf("length %d: %w", length, ErrStringTooLong)
+	}
+	return nil
+}
+
+// maxStringLength returns the maximum length of an encoded string in the given byte order.
+func maxStringLength(order binary.ByteOrder) int {
+	if isVarint(order) {
+		return math.MaxInt32
+	}
+	return math.MaxUint16
+}
+
+// modifiedUTF8Length returns the length of the given string in Modified UTF-8, without
+// encoding it.
+func modifiedUTF8Length(s string) int {
+	length := len(s)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == 0 {
+			length++ // 0xC0 0x80
+		} else if size == 4 {
+			length += 2 // two surrogates with three bytes each
+		}
+		i += size
+	}
+	return length
+}
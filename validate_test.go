@@ -0,0 +1,146 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestValidateSuite(t *testing.T) {
+	suite.Run(t, new(ValidateSuite))
+}
+
+type ValidateSuite struct {
+	suite.Suite
+}
+
+func (suite *ValidateSuite) encodeError(tag Tag, opts ...EncoderOption) *EncodeError {
+	var buf bytes.Buffer
+	err := NewEncoder(&buf, binary.BigEndian, opts...).WriteTag(tag)
+	suite.Empty(buf.Bytes(), "nothing must be written")
+
+	var encodeErr *EncodeError
+	if suite.True(errors.As(err, &encodeErr), "%v", err) {
+		return encodeErr
+	}
+	return &EncodeError{}
+}
+
+func (suite *ValidateSuite) TestStringTooLong() {
+	long := strings.Repeat("a", 65536)
+
+	err := suite.encodeError(NewCompoundTag("", []Tag{
+		NewCompoundTag("Level", []Tag{NewStringTag("text", long)}),
+	}))
+	suite.Equal("Level.text", err.Path)
+	suite.ErrorIs(err, ErrStringTooLong)
+
+	err = suite.encodeError(NewCompoundTag("", []Tag{NewByteTag(long, 1)}))
+	suite.Equal(long, err.Path)
+	suite.ErrorIs(err, ErrStringTooLong)
+
+	err = suite.encodeError(NewStringTag(long, ""))
+	suite.Equal("", err.Path)
+	suite.ErrorIs(err, ErrStringTooLong)
+
+	// NUL takes two bytes in Modified UTF-8
+	err = suite.encodeError(NewStringTag("", strings.Repeat("\x00", 40000)))
+	suite.ErrorIs(err, ErrStringTooLong)
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian, RawStrings()).WriteTag(NewStringTag("", strings.Repeat("\x00", 40000))))
+	suite.NoError(NewEncoder(&buf, NetworkLittleEndian).WriteTag(NewStringTag("", long)))
+}
+
+func (suite *ValidateSuite) TestListElementType() {
+	err := suite.encodeError(NewCompoundTag("", []Tag{
+		NewCompoundTag("Level", []Tag{
+			NewListTag("Sections", []Tag{
+				NewCompoundTag("", nil),
+				NewIntTag("", 1),
+			}, IDTagCompound),
+		}),
+	}))
+	suite.Equal("Level.Sections[1]", err.Path)
+	suite.ErrorIs(err, ErrListElementType)
+
+	err = suite.encodeError(NewListTag("", []Tag{NewEndTag()}, IDTagEnd))
+	suite.ErrorIs(err, ErrListType)
+	err = suite.encodeError(NewListTag("", nil, NumIDTags))
+	suite.ErrorIs(err, ErrListType)
+}
+
+func (suite *ValidateSuite) TestUnexpectedEnd() {
+	compound := NewCompoundTag("", nil)
+	compound.Value["end"] = NewEndTag()
+	err := suite.encodeError(compound)
+	suite.Equal("end", err.Path)
+	suite.ErrorIs(err, ErrUnexpectedEnd)
+
+	// an end tag as root is written like it is read, without a name
+	var buf bytes.Buffer
+	suite.NoError(NewEncoder(&buf, binary.BigEndian).WriteTag(NewEndTag()))
+	suite.Equal([]byte{byte(IDTagEnd)}, buf.Bytes())
+}
+
+func (suite *ValidateSuite) TestNil() {
+	err := suite.encodeError(NewListTag("list", []Tag{NewListTag("", []Tag{nil}, IDTagInt)}, IDTagList))
+	suite.Equal("[0][0]", err.Path)
+	suite.ErrorIs(err, ErrNilTag)
+
+	compound := NewCompoundTag("", nil)
+	compound.Value["a"] = nil
+	err = suite.encodeError(compound, WithCompression(CompressionGzip))
+	suite.Equal("a", err.Path)
+	suite.ErrorIs(err, ErrNilTag)
+
+	err = suite.encodeError(nil)
+	suite.ErrorIs(err, ErrNilTag)
+}
+
+func (suite *ValidateSuite) TestWriteTo() {
+	// writing a tag directly doesn't validate it, but must not truncate the length
+	var buf bytes.Buffer
+	err := NewStringTag("", strings.Repeat("a", 65536)).WriteTo(&buf, binary.BigEndian)
+	suite.ErrorIs(err, ErrStringTooLong)
+}
+
+// recordingTag records the writers that are passed to its WriteTo method.
+type recordingTag struct {
+	Tag
+	writers *[]io.Writer
+}
+
+func (t recordingTag) WriteTo(w io.Writer, order binary.ByteOrder) error {
+	*t.writers = append(*t.writers, w)
+	return t.Tag.WriteTo(w, order)
+}
+
+func (suite *ValidateSuite) TestValidateOnce() {
+	// tags are only validated by the encoder of the root tag, which is the case
+	// if all tags are written to an *encodeWriter, so that the encoders of the
+	// nested tags know that they are nested
+	var writers []io.Writer
+	tag := func() Tag {
+		return recordingTag{NewCompoundTag("", []Tag{
+			NewListTag("list", []Tag{
+				recordingTag{NewCompoundTag("", []Tag{NewIntTag("x", 1)}), &writers},
+			}, IDTagCompound),
+		}), &writers}
+	}
+
+	suite.NoError(NewEncoder(ioutil.Discard, binary.BigEndian).WriteTag(tag()))
+	tw := NewTokenWriter(ioutil.Discard, binary.BigEndian)
+	suite.NoError(tw.WriteTag(tag()))
+	suite.NoError(tw.Close())
+
+	suite.Require().Len(writers, 4)
+	for _, w := range writers {
+		suite.IsType(&encodeWriter{}, w)
+	}
+}